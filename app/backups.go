@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/log"
+
+	"github.com/timmo001/bootstrap/backups"
+	"github.com/timmo001/bootstrap/steps"
+)
+
+// backupsCommand lists the files bootstrap backed up before changing them.
+func backupsCommand(args []string) {
+	if len(args) > 0 && args[0] != "ls" {
+		log.Fatalf("unknown backups command: %s", args[0])
+	}
+
+	entries, err := backups.List()
+	if err != nil {
+		log.Fatalf("error: %v", err)
+	}
+	if len(entries) == 0 {
+		log.Infof("No backups in %s", backups.Dir())
+		return
+	}
+	for _, e := range entries {
+		fmt.Printf("%-16s %-8s %s\n", e.RunID, e.Kind, e.Path)
+	}
+}
+
+// restoreCommand puts files back as they were before a run changed them.
+func restoreCommand(ctx *steps.Context, args []string) {
+	if len(args) == 0 {
+		log.Fatal("usage: bootstrap restore <path...> [--run <run-id>]")
+	}
+
+	for _, path := range args {
+		if rest, ok := strings.CutPrefix(path, "~/"); ok {
+			path = filepath.Join(ctx.Home, rest)
+		}
+		e, err := backups.Restore(path, runID)
+		if err != nil {
+			log.Fatalf("error: %v", err)
+		}
+		log.Infof("Restored %s from run %s", e.Path, e.RunID)
+	}
+}
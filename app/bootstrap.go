@@ -2,796 +2,468 @@ package main
 
 import (
 	"flag"
+	"fmt"
+	"io"
 	"os"
 	"strings"
 
 	"github.com/charmbracelet/huh"
 	"github.com/charmbracelet/log"
 
+	"github.com/timmo001/bootstrap/backups"
+	"github.com/timmo001/bootstrap/components"
+	"github.com/timmo001/bootstrap/dotfiles"
+	"github.com/timmo001/bootstrap/events"
+	"github.com/timmo001/bootstrap/state"
+	"github.com/timmo001/bootstrap/steps"
+	"github.com/timmo001/bootstrap/tui"
 	u "github.com/timmo001/bootstrap/utils"
 )
 
-var forceInstall bool
+var (
+	forceInstall bool
+	only         string
+	skip         string
+	tags         string
+	pick         bool
+	dashboard    bool
+	failureLines int
+	logFormat    string
+	eventsPath   string
+	runID        string
+	pins         string
+	dryRun       bool
+	dotfileList  string
+	conflict     string
+	signing      string
+	githubLogin  bool
+	clonePolicy  string
+	mirrors      string
+	dirty        string
+	gitCache     bool
+	fonts        string
+	systemFonts  bool
+	releasesAPI  string
+
+	command string
+	args    []string
+)
 
 func init() {
 	flag.BoolVar(&forceInstall, "force", false, "Force install all packages")
+	flag.StringVar(&only, "only", "", "Comma separated list of steps to run (dependencies are included)")
+	flag.StringVar(&skip, "skip", "", "Comma separated list of steps to skip")
+	flag.StringVar(&tags, "tags", "", "Comma separated list of tags to run, e.g. desktop,dev,gaming,hyprland")
+	flag.BoolVar(&pick, "pick", false, "Interactively pick the components to apply")
+	flag.BoolVar(&dashboard, "tui", false, "Show a progress dashboard instead of the plain log")
+	flag.IntVar(&failureLines, "failure-lines", 40, "Number of log lines to print when a step fails")
+	flag.StringVar(&logFormat, "log-format", "text", "Log format: text, json or logfmt")
+	flag.StringVar(&eventsPath, "events", "", "Write a JSON Lines stream of progress events to this file")
+	flag.StringVar(&runID, "run", "", "Run ID for the logs command (defaults to the latest run) or the restore command (defaults to the oldest backup)")
+	flag.StringVar(&pins, "pin", "", "Comma separated list of refs or release versions to install components from, e.g. neovim@v0.10.x or sunshine@0.23.x (an empty ref unpins)")
+	flag.BoolVar(&dryRun, "dry-run", false, "Show what the selected steps would change without running them")
+	flag.StringVar(&dotfileList, "dotfiles", "", "Comma separated list of dotfiles packages to link (defaults to all)")
+	flag.StringVar(&conflict, "dotfiles-conflict", dotfiles.ConflictFail, "What to do with existing files in the way of dotfiles: fail, adopt or backup")
+	flag.BoolVar(&githubLogin, "github-login", false, "Log in to GitHub with gh and add the ssh key to the account")
+	flag.StringVar(&signing, "signing", "", "Sign commits with an ssh or gpg key, generating one if needed")
+	flag.StringVar(&clonePolicy, "clone", u.CloneAuto, "Which git URLs to try first: auto, ssh or https (public repos fall back to https)")
+	flag.StringVar(&mirrors, "git-mirror", "", "Comma separated list of <url>=<mirror> rewrites for git, e.g. https://github.com/=https://git.example.com/github/")
+	flag.StringVar(&dirty, "dirty", u.DirtyAbort, "What to do with uncommitted changes in repos bootstrap updates: abort or stash")
+	flag.BoolVar(&gitCache, "git-cache", false, "Clone through bare mirrors kept in ~/.cache/bootstrap/git")
+	flag.StringVar(&fonts, "fonts", "", "Comma separated list of Nerd Fonts families to install, the first is used as the monospace font (defaults to FiraMono,Hack,JetBrainsMono)")
+	flag.BoolVar(&systemFonts, "system-fonts", false, "Install fonts to /usr/local/share/fonts for every user")
+	flag.StringVar(&releasesAPI, "releases-api", u.ReleasesAPI, "GitHub API to look up releases in, or a directory laid out like it")
 	flag.Parse()
+
+	// Flags may also follow the command, e.g. "doctor --only git"
+	if flag.NArg() > 0 {
+		command = flag.Arg(0)
+		args = parseArgs(flag.CommandLine, flag.Args()[1:])
+	}
 }
 
 func main() {
-	shell := os.Getenv("SHELL")
-	home := os.Getenv("HOME")
+	switch logFormat {
+	case "text":
+	case "json":
+		log.SetFormatter(log.JSONFormatter)
+	case "logfmt":
+		log.SetFormatter(log.LogfmtFormatter)
+	default:
+		log.Fatalf("unknown log format: %s", logFormat)
+	}
 
-	log.Info("Bootstrapping...")
+	registry := steps.NewRegistry()
+	components.Register(registry)
 
-	var installedPackages []string
+	filter := steps.Filter{
+		Only: u.SplitList(only),
+		Skip: u.SplitList(skip),
+		Tags: u.SplitList(tags),
+	}
+	if err := registry.Validate(filter); err != nil {
+		log.Fatalf("error: %v", err)
+	}
+
+	if pins != "" {
+		if err := savePins(registry, u.SplitList(pins)); err != nil {
+			log.Fatalf("error: %v", err)
+		}
+	}
+
+	switch conflict {
+	case dotfiles.ConflictFail, dotfiles.ConflictAdopt, dotfiles.ConflictBackup:
+	default:
+		log.Fatalf("unknown dotfiles conflict policy: %s", conflict)
+	}
+	switch clonePolicy {
+	case u.CloneAuto, u.CloneSSH, u.CloneHTTPS:
+		u.ClonePolicy = clonePolicy
+	default:
+		log.Fatalf("unknown clone policy: %s", clonePolicy)
+	}
+	switch dirty {
+	case u.DirtyAbort, u.DirtyStash:
+		u.DirtyPolicy = dirty
+	default:
+		log.Fatalf("unknown dirty repo policy: %s", dirty)
+	}
+	u.ReleasesAPI = releasesAPI
+	if gitCache {
+		u.GitCache = gitCacheDir()
+	}
+	gitMirrors, err := parseMirrors(u.SplitList(mirrors))
+	if err != nil {
+		log.Fatalf("error: %v", err)
+	}
+	switch signing {
+	case "", steps.SigningSSH, steps.SigningGPG:
+	default:
+		log.Fatalf("unknown signing key kind: %s", signing)
+	}
+
+	ctx := &steps.Context{
+		Home:             os.Getenv("HOME"),
+		Shell:            os.Getenv("SHELL"),
+		Force:            forceInstall,
+		Email:            "aidan@timmo.dev",
+		Name:             "Aidan Timson",
+		Dotfiles:         u.SplitList(dotfileList),
+		DotfilesConflict: conflict,
+		GitMirrors:       gitMirrors,
+		GitHubLogin:      githubLogin,
+		Signing:          signing,
+		Fonts:            u.SplitList(fonts),
+		SystemFonts:      systemFonts,
+	}
+	detectEnvironment(ctx)
+	steps.ApplyInstalledEnv(ctx, registry.Steps())
+	if githubLogin {
+		// The key is added to GitHub with gh, so it has to be installed first
+		ssh, _ := registry.Get("ssh")
+		ssh.Requires = append(ssh.Requires, "gh")
+	}
+
+	switch command {
+	case "":
+	case "logs":
+		logsCommand(args)
+		return
+	case "doctor":
+		doctorCommand(ctx, registry, filter)
+		return
+	case "update":
+		updateCommand(ctx, registry, args)
+		return
+	case "uninstall":
+		uninstallCommand(ctx, registry, args)
+		return
+	case "rollback":
+		rollbackCommand(ctx, registry, args)
+		return
+	case "backups":
+		backupsCommand(args)
+		return
+	case "restore":
+		restoreCommand(ctx, args)
+		return
+	case "cache":
+		cacheCommand(args)
+		return
+	default:
+		log.Fatalf("unknown command: %s", command)
+	}
+
+	log.Info("Bootstrapping...")
 
 	// Ask if the user is running on a desktop environment
 	u.PrintSeparator("Checking if running on a desktop environment")
-	isDesktop := false
-	isWSL := false
-	email := "aidan@timmo.dev"
-	name := "Aidan Timson"
+	work := steps.GitProfile{Name: "work", Dir: "~/work/", User: ctx.Name}
 	form := huh.NewForm(
 		huh.NewGroup(
 			huh.NewConfirm().
 				Title("Are you running on a desktop environment?").
-				Value(&isDesktop),
+				Value(&ctx.IsDesktop),
 		).Title("Desktop"),
 		huh.NewGroup(
 			huh.NewConfirm().
 				Title("Are you on WSL? 🤮").
-				Value(&isWSL),
+				Value(&ctx.IsWSL),
 		).Title("WSL"),
 		huh.NewGroup(
 			huh.NewInput().
 				Title("What is your email?").
-				Value(&email),
+				Value(&ctx.Email),
 			huh.NewInput().
 				Title("What is your name?").
-				Value(&name),
+				Value(&ctx.Name),
 		).Title("Git config"),
+		huh.NewGroup(
+			huh.NewInput().
+				Title("What is your work email?").
+				Description("Used for repos under "+work.Dir+", leave empty to skip").
+				Value(&work.Email),
+			huh.NewInput().
+				Title("What is your name at work?").
+				Value(&work.User),
+		).Title("Work git identity"),
+		huh.NewGroup(
+			huh.NewSelect[string]().
+				Title("Sign commits with").
+				Options(
+					huh.NewOption("Don't sign commits", ""),
+					huh.NewOption("SSH key", steps.SigningSSH),
+					huh.NewOption("GPG key", steps.SigningGPG),
+				).
+				Value(&ctx.Signing),
+		).Title("Commit signing"),
 	)
 	if err := form.Run(); err != nil {
 		log.Fatalf("error: %v", err)
 	}
-
-	// Update apt
-	if err := u.RunCmd("sudo", "apt", "update"); err != nil {
-		log.Fatalf("error: %v", err)
-	}
-
-	// Upgrade apt
-	u.PrintSeparator("Upgrade apt packages")
-	if err := u.RunCmd("sudo", "apt", "full-upgrade", "-y"); err != nil {
-		log.Fatalf("error: %v", err)
-	}
-
-	// Cleanup apt
-	u.PrintSeparator("Cleanup apt packages")
-	if err := u.RunCmd("sudo", "apt", "autoremove", "-y"); err != nil {
-		log.Fatalf("error: %v", err)
-	}
-
-	// Copy .editorconfig
-	u.PrintSeparator("Copying .editorconfig")
-	if err := u.RunCmd("cp", ".editorconfig", home); err != nil {
-		log.Fatalf("error: %v", err)
-	}
-
-	// Exit if the shell is not zsh
-	u.PrintSeparator("Checking shell")
-	if !strings.Contains(shell, "zsh") {
-		log.Fatalf("Please restart your shell and run the script again in zsh to continue.")
+	if work.Email != "" {
+		ctx.GitProfiles = append(ctx.GitProfiles, work)
 	}
 
-	// Install wget
-	u.PrintSeparator("wget")
-	if forceInstall || !u.IsExecutableInstalled("wget") {
-		if err := u.RunCmd("sudo", "apt", "install", "wget", "-y"); err != nil {
+	// Let the user pick components, starting from their last selection
+	if pick {
+		u.PrintSeparator("Picking components")
+		st, err := state.Load()
+		if err != nil {
 			log.Fatalf("error: %v", err)
 		}
-		installedPackages = append(installedPackages, "wget")
-	}
-
-	// Install curl
-	u.PrintSeparator("curl")
-	if forceInstall || !u.IsExecutableInstalled("curl") {
-		if err := u.RunCmd("sudo", "apt", "install", "curl", "-y"); err != nil {
+		picked, err := registry.Pick(ctx, st.Selection)
+		if err != nil {
 			log.Fatalf("error: %v", err)
 		}
-		installedPackages = append(installedPackages, "curl")
-	}
-
-	// Setup flatpak and flathub
-	u.PrintSeparator("Setting up flatpak and flathub")
-	if err := u.RunCmd("sudo", "apt", "install", "flatpak", "-y"); err != nil {
-		log.Fatalf("error: %v", err)
-	}
-	if err := u.RunCmd("flatpak", "remote-add", "--if-not-exists", "flathub", "https://flathub.org/repo/flathub.flatpakrepo"); err != nil {
-		log.Fatalf("error: %v", err)
-	}
-	if err := u.RunCmd("sudo", "apt", "install", "gnome-software-plugin-flatpak", "-y"); err != nil {
-		log.Fatalf("error: %v", err)
-	}
-
-	// Install pipewire and wireplumber
-	u.PrintSeparator("pipewire and wireplumber")
-	if err := u.RunCmd("sudo", "apt", "install", "pipewire", "pipewire-audio-client-libraries", "wireplumber", "-y"); err != nil {
-		log.Fatalf("error: %v", err)
-	}
-
-	// Install git
-	u.PrintSeparator("git")
-	if forceInstall || !u.IsExecutableInstalled("git") {
-		if err := u.RunCmd("sudo", "apt", "install", "git", "-y"); err != nil {
-			log.Fatalf("error: %v", err)
+		if len(picked) == 0 {
+			log.Fatal("No components selected.")
 		}
-		installedPackages = append(installedPackages, "git")
-	}
-	err := u.RunCmd("git", "config", "--global", "pull.rebase", "true")
-	if err != nil {
-		log.Fatalf("error: %v", err)
-	}
-	err = u.RunCmd("git", "config", "--global", "rebase.autoStash", "true")
-	if err != nil {
-		log.Fatalf("error: %v", err)
-	}
-	err = u.RunCmd("git", "config", "--global", "core.editor", "nvim")
-	if err != nil {
-		log.Fatalf("error: %v", err)
-	}
-	err = u.RunCmd("git", "config", "--global", "push.default", "current")
-	if err != nil {
-		log.Fatalf("error: %v", err)
-	}
-	err = u.RunCmd("git", "config", "--global", "user.email", email)
-	if err != nil {
-		log.Fatalf("error: %v", err)
-	}
-	err = u.RunCmd("git", "config", "--global", "user.name", name)
-	if err != nil {
-		log.Fatalf("error: %v", err)
-	}
-
-	// Install gh
-	u.PrintSeparator("GitHub CLI (gh)")
-	if forceInstall || !u.IsExecutableInstalled("gh") {
-		if err := u.RunCmd("sudo", "mkdir", "-p", "-m", "775", "/etc/apt/keyrings"); err != nil {
-			log.Fatalf("error: %v", err)
-		}
-		if err := u.DownloadFile("https://cli.github.com/packages/githubcli-archive-keyring.gpg", "githubcli-archive-keyring.gpg"); err != nil {
+		st.Selection = picked
+		if err := st.Save(); err != nil {
 			log.Fatalf("error: %v", err)
 		}
-		if err := u.RunCmd("sudo", "mv", "githubcli-archive-keyring.gpg", "/etc/apt/keyrings/githubcli-archive-keyring.gpg"); err != nil {
-			log.Fatalf("error: %v", err)
-		}
-		if err := u.RunCmd("sudo", "chmod", "go+r", "/etc/apt/keyrings/githubcli-archive-keyring.gpg"); err != nil {
-			log.Fatalf("error: %v", err)
-		}
-		if err := u.RunCmd("echo", "deb [arch=$(dpkg --print-architecture) signed-by=/etc/apt/keyrings/githubcli-archive-keyring.gpg] https://cli.github.com/packages stable main | sudo tee /etc/apt/sources.list.d/github-cli.list > /dev/null"); err != nil {
-			log.Fatalf("error: %v", err)
-		}
-		if err := u.RunCmd("sudo", "apt", "update"); err != nil {
-			log.Fatalf("error: %v", err)
-		}
-		if err := u.RunCmd("sudo", "apt", "install", "gh", "-y"); err != nil {
-			log.Fatalf("error: %v", err)
-		}
-		installedPackages = append(installedPackages, "gh")
-	}
-
-	// Install stow
-	u.PrintSeparator("stow")
-	if forceInstall || !u.IsExecutableInstalled("stow") {
-		if err := u.RunCmd("sudo", "apt", "install", "stow", "-y"); err != nil {
-			log.Fatalf("error: %v", err)
-		}
-		installedPackages = append(installedPackages, "stow")
+		filter.Only, filter.Tags = picked, nil
 	}
 
-	// Setup dotfiles
-	u.PrintSeparator("Setting up dotfiles")
-	dotfilesPath := home + "/.config/dotfiles"
-	if err := u.UpdateOrCloneRepo("git@github.com:timmo001/dotfiles", dotfilesPath); err != nil {
-		log.Fatalf("error: %v", err)
-	}
-	if err := u.RunCmdInDir(dotfilesPath, "./install.sh"); err != nil {
+	// Work out which steps to run
+	u.PrintSeparator("Selecting steps")
+	selection, err := registry.Select(ctx, filter)
+	if err != nil {
 		log.Fatalf("error: %v", err)
 	}
+	selection.Print()
 
-	// Install ruby
-	u.PrintSeparator("ruby")
-	if forceInstall || !u.IsExecutableInstalled("ruby") {
-		if err := u.RunCmd("sudo", "apt", "install", "ruby", "-y"); err != nil {
-			log.Fatalf("error: %v", err)
-		}
-		installedPackages = append(installedPackages, "ruby")
+	if err := execute(ctx, selection, selection.Steps()); err != nil {
+		log.Fatalf("error: %v", err)
 	}
 
-	// Install zsh-autosuggestions
-	u.PrintSeparator("zsh-autosuggestions")
-	if forceInstall || !u.IsExecutableInstalled("zsh-autosuggestions") {
-		if err := u.RunCmd("sudo", "apt", "install", "zsh-autosuggestions", "-y"); err != nil {
-			log.Fatalf("error: %v", err)
-		}
-		installedPackages = append(installedPackages, "zsh-autosuggestions")
-	}
+	log.Info("Bootstrapping complete.")
+}
 
-	// Install zsh-syntax-highlighting
-	u.PrintSeparator("zsh-syntax-highlighting")
-	if forceInstall || !u.IsExecutableInstalled("zsh-syntax-highlighting") {
-		if err := u.RunCmd("sudo", "apt", "install", "zsh-syntax-highlighting", "-y"); err != nil {
-			log.Fatalf("error: %v", err)
-		}
-		installedPackages = append(installedPackages, "zsh-syntax-highlighting")
+// execute runs the steps with logs, events, the dashboard and the report
+// wired up. selection is the full decision list used for the report.
+func execute(ctx *steps.Context, selection *steps.Selection, toRun []*steps.Step) error {
+	if dryRun {
+		return steps.PrintPlan(ctx, toRun)
 	}
 
-	// Install oh-my-zsh
-	u.PrintSeparator("oh-my-zsh")
-	exists, err := u.ExistsDir(home + "/.oh-my-zsh")
+	// Capture each step's output under the run's log directory
+	run, err := state.NewRun()
 	if err != nil {
-		log.Fatalf("error: %v", err)
+		return err
 	}
-	if forceInstall || !exists {
-		if err := u.DeleteDir(home + "/.oh-my-zsh"); err != nil {
-			log.Fatalf("error: %v", err)
-		}
-		if err := u.DownloadFile("https://raw.github.com/ohmyzsh/ohmyzsh/master/tools/install.sh", "omz-install.sh"); err != nil {
-			log.Fatalf("error: %v", err)
-		}
-		if err := u.RunCmdNoInput("sh", "omz-install.sh"); err != nil {
-			log.Errorf("error: %v", err)
-			if err := u.DeleteFile("omz-install.sh"); err != nil {
-				log.Fatalf("error: %v", err)
-			}
-			log.Fatal("error installing oh-my-zsh")
-		}
-		if err := u.DeleteFile("omz-install.sh"); err != nil {
-			log.Fatalf("error: %v", err)
-		}
-		installedPackages = append(installedPackages, "oh-my-zsh")
+	log.Infof("Logging to %s", run.Dir)
+	backups.SetRun(run.ID)
+	report := steps.NewReport(ctx, run.ID, selection)
+	observers := []steps.Observer{
+		&steps.LogCapture{Dir: run.Dir, Lines: failureLines},
+		report,
 	}
 
-	// Download omz plugins
-	u.PrintSeparator("Downloading oh-my-zsh plugins")
-	pluginsDir := home + "/.oh-my-zsh/custom/plugins"
-	if err := u.UpdateOrCloneRepo("git@github.com:zsh-users/zsh-autosuggestions.git", pluginsDir+"/zsh-autosuggestions"); err != nil {
-		log.Errorf("error: %v", err)
-	}
-	if err := u.UpdateOrCloneRepo("git@github.com:zsh-users/zsh-syntax-highlighting.git", pluginsDir+"/zsh-syntax-highlighting"); err != nil {
-		log.Errorf("error: %v", err)
-	}
-	if err := u.UpdateOrCloneRepo("git@github.com:zdharma-continuum/fast-syntax-highlighting.git", pluginsDir+"/fast-syntax-highlighting"); err != nil {
-		log.Errorf("error: %v", err)
-	}
-	if err := u.UpdateOrCloneRepo("git@github.com:marlonrichert/zsh-autocomplete.git", pluginsDir+"/zsh-autocomplete"); err != nil {
-		log.Errorf("error: %v", err)
-	}
-
-	// Install starship
-	u.PrintSeparator("starship")
-	if forceInstall || !u.IsExecutableInstalled("starship") {
-		if err := u.RunCmd("curl", "-fsSL", "https://starship.rs/install.sh", "-o", "starship-install.sh"); err != nil {
-			log.Fatalf("error: %v", err)
-		}
-		if err := u.RunCmd("chmod", "+x", "starship-install.sh"); err != nil {
-			log.Fatalf("error: %v", err)
-		}
-		if err := u.RunCmd("./starship-install.sh", "--yes"); err != nil {
-			log.Fatalf("error: %v", err)
+	if eventsPath != "" {
+		f, err := os.Create(eventsPath)
+		if err != nil {
+			return err
 		}
-		if err := u.DeleteFile("starship-install.sh"); err != nil {
-			log.Fatalf("error: %v", err)
-		}
-		installedPackages = append(installedPackages, "starship")
-	}
-
-	// Install nodejs
-	u.PrintSeparator("Node.js")
-	if err := u.DownloadFile("https://fnm.vercel.app/install", "fnm-install.sh"); err != nil {
-		log.Fatalf("error: %v", err)
-	}
-	if err := u.RunCmd("chmod", "+x", "fnm-install.sh"); err != nil {
-		log.Fatalf("error: %v", err)
-	}
-	if err := u.RunCmd("./fnm-install.sh", "--skip-shell"); err != nil {
-		log.Fatalf("error: %v", err)
-	}
-	if err := u.DeleteFile("fnm-install.sh"); err != nil {
-		log.Fatalf("error: %v", err)
-	}
-	if err := u.RunCmd("fnm", "install", "22"); err != nil {
-		log.Fatalf("error: %v", err)
+		defer f.Close()
+		events.SetOutput(f)
+		observers = append(observers, &steps.EventEmitter{})
 	}
 
-	// Install python + dependencies
-	u.PrintSeparator("Python and dependencies")
-	if err := u.RunCmd("sudo", "apt", "install", "python3", "python3-dev", "-y"); err != nil {
-		log.Fatalf("error: %v", err)
+	if dashboard && !tui.Available() {
+		log.Warn("stdout is not a terminal, falling back to the plain log")
+		dashboard = false
 	}
-	if err := u.RunCmd("sudo", "apt", "install", "python3-pip", "-y"); err != nil {
-		log.Fatalf("error: %v", err)
-	}
-	if err := u.RunCmd("sudo", "apt", "install", "python3-venv", "-y"); err != nil {
-		log.Fatalf("error: %v", err)
-	}
-	if err := u.RunCmd(
-		"sudo", "apt", "install", "autoconf", "libssl-dev", "libxml2-dev", "libxslt1-dev", "libjpeg-dev", "libffi-dev",
-		"libudev-dev", "zlib1g-dev", "pkg-config", "libavformat-dev", "libavcodec-dev", "libavdevice-dev", "libavutil-dev",
-		"libswscale-dev", "libswresample-dev", "libavfilter-dev", "ffmpeg", "libgammu-dev", "-y",
-	); err != nil {
-		log.Fatalf("error: %v", err)
-	}
-
-	// Install rust
-	u.PrintSeparator("Rust")
-	if forceInstall || !u.IsExecutableInstalled("rustc") {
-		if err := u.DownloadFile("https://sh.rustup.rs", "rustup-init.sh"); err != nil {
-			log.Fatalf("error: %v", err)
-		}
-		if err := u.RunCmd("chmod", "+x", "rustup-init.sh"); err != nil {
-			log.Fatalf("error: %v", err)
-		}
-		if err := u.RunCmd("./rustup-init.sh", "-y"); err != nil {
-			log.Fatalf("error: %v", err)
-		}
-		if err := u.DeleteFile("rustup-init.sh"); err != nil {
-			log.Fatalf("error: %v", err)
-		}
-		installedPackages = append(installedPackages, "rust")
+	if s := steps.FindInteractive(ctx, toRun); dashboard && s != nil {
+		log.Warnf("%s prompts in the terminal, falling back to the plain log", s.ID)
+		dashboard = false
 	}
-
-	// Install zig
-	u.PrintSeparator("Zig")
-	if err := u.RunCmd("sudo", "snap", "install", "zig", "--classic", "--beta"); err != nil {
-		log.Fatalf("error: %v", err)
+	if dashboard {
+		err = tui.Run(ctx, toRun, observers...)
+	} else {
+		err = steps.Run(ctx, toRun, observers...)
 	}
-	installedPackages = append(installedPackages, "zig")
-
-	// Install docker
-	if !isWSL {
-		u.PrintSeparator("Docker")
-		if forceInstall || !u.IsExecutableInstalled("docker") {
-			if err := u.DownloadFile("https://get.docker.com", "docker-install.sh"); err != nil {
-				log.Fatalf("error: %v", err)
-			}
-			if err := u.RunCmd("chmod", "+x", "docker-install.sh"); err != nil {
-				log.Fatalf("error: %v", err)
-			}
-			if err := u.RunCmd("./docker-install.sh"); err != nil {
-				log.Fatalf("error: %v", err)
-			}
-			if err := u.DeleteFile("docker-install.sh"); err != nil {
-				log.Fatalf("error: %v", err)
-			}
-			installedPackages = append(installedPackages, "docker")
-		}
 
-		// Install docker compose
-		u.PrintSeparator("Docker Compose")
-		if err := u.RunCmd("sudo", "apt", "install", "docker-compose-plugin", "-y"); err != nil {
-			log.Fatalf("error: %v", err)
-		}
-		installedPackages = append(installedPackages, "docker-compose-plugin")
+	// Point the shells at whatever is installed now
+	var all []*steps.Step
+	for _, d := range selection.Decisions {
+		all = append(all, d.Step)
 	}
-
-	// Install homebrew
-	u.PrintSeparator("Homebrew")
-	if forceInstall || !u.IsExecutableInstalled("brew") {
-		if err := u.DownloadFile("https://raw.githubusercontent.com/Homebrew/install/HEAD/install.sh", "brew-install.sh"); err != nil {
-			log.Fatalf("error: %v", err)
-		}
-		if err := u.RunCmd("chmod", "+x", "brew-install.sh"); err != nil {
-			log.Fatalf("error: %v", err)
-		}
-		if err := u.RunCmd("./brew-install.sh"); err != nil {
-			log.Fatalf("error: %v", err)
-		}
-		if err := u.DeleteFile("brew-install.sh"); err != nil {
-			log.Fatalf("error: %v", err)
-		}
-		installedPackages = append(installedPackages, "homebrew")
-	}
-
-	// // Setup .zshrc
-	// u.PrintSeparator("Setting up .zshrc")
-	// zshrcPath := home + "/.zshrc"
-	// log.Infof("Setting up %s", zshrcPath)
-
-	// // Open ./.zshrc
-	// f, err := os.OpenFile("./.zshrc", os.O_RDONLY, 0644)
-	// if err != nil {
-	// 	log.Fatalf("error: %v", err)
-	// }
-	// defer f.Close()
-	// log.Infof("Reading %s", f.Name())
-	// // Read the file line by line using scanner
-	// scanner := bufio.NewScanner(f)
-	// for scanner.Scan() {
-	// 	// Read the file line by line
-	// 	line := scanner.Text()
-
-	// 	log.Infof("Line: %s", line)
-
-	// 	// Add the line to ~/.zshrc
-	// 	if err := addIfMissingToFile(zshrcPath, line); err != nil {
-	// 		log.Fatalf("error: %v", err)
-	// 	}
-	// }
-
-	// Enable yarn
-	u.PrintSeparator("Enabling Yarn")
-	if err := u.RunCmd("corepack", "enable", "yarn"); err != nil {
+	if err := steps.WriteEnvFile(ctx, all); err != nil {
 		log.Errorf("error: %v", err)
 	}
 
-	// Enable pnpm
-	u.PrintSeparator("Enabling pnpm")
-	if err := u.RunCmd("corepack", "enable", "pnpm"); err != nil {
+	// Summarise the run, whether or not it succeeded
+	report.Finish()
+	fmt.Println(report.Table())
+	if err := report.Write(run.Dir); err != nil {
 		log.Errorf("error: %v", err)
+	} else {
+		log.Infof("Report written to %s", run.Dir)
 	}
 
-	// Install markdownlint
-	u.PrintSeparator("markdownlint")
-	if err := u.RunCmd("sudo", "gem", "install", "mdl"); err != nil {
-		log.Fatalf("error: %v", err)
-	}
+	return err
+}
 
-	// Install neovim
-	u.PrintSeparator("Neovim")
-	if err := u.RunCmd("sudo", "apt", "install", "ninja-build", "gettext", "cmake", "unzip", "curl", "build-essential", "-y"); err != nil {
-		log.Fatalf("error: %v", err)
-	}
-	if err := u.UpdateOrCloneRepo("git@github.com:neovim/neovim", "neovim"); err != nil {
-		log.Fatalf("error: %v", err)
-	}
-	if err := u.RunCmdInDir("neovim", "make", "CMAKE_BUILD_TYPE=Release"); err != nil {
-		log.Fatalf("error: %v", err)
-	}
-	if err := u.RunCmdInDir("neovim", "sudo", "make", "install"); err != nil {
-		log.Fatalf("error: %v", err)
-	}
-	if err := u.RunCmd("npm", "install", "-g", "neovim"); err != nil {
-		log.Fatalf("error: %v", err)
-	}
-	// if err := deleteDir("neovim"); err != nil {
-	// 	log.Fatalf("error: %v", err)
-	// }
-
-	// // Install neovim config
-	// u.PrintSeparator("Neovim config")
-	// if err := deleteDir(home + "/.config/nvim"); err != nil {
-	// 	log.Fatalf("error: %v", err)
-	// }
-	// if err := updateOrCloneRepo("git@github.com:timmo001/nvim-config", home+"/.config/nvim"); err != nil {
-	// 	log.Fatalf("error: %v", err)
-	// }
-	// if err := u.RunCmd("nvim", "+PlugInstall", "+qall"); err != nil {
-	// 	log.Fatalf("error: %v", err)
-	// }
-
-	// Install ascii-image-converter
-	u.PrintSeparator("ascii-image-converter")
-	if err := u.RunCmd("go", "install", "github.com/TheZoraiz/ascii-image-converter@latest"); err != nil {
-		log.Fatalf("error: %v", err)
+// detectEnvironment fills in the desktop and WSL answers from the
+// environment, as defaults for the form and for commands that don't ask.
+func detectEnvironment(ctx *steps.Context) {
+	ctx.IsDesktop = os.Getenv("XDG_CURRENT_DESKTOP") != "" || os.Getenv("WAYLAND_DISPLAY") != "" || os.Getenv("DISPLAY") != ""
+	if version, err := os.ReadFile("/proc/version"); err == nil {
+		ctx.IsWSL = strings.Contains(strings.ToLower(string(version)), "microsoft")
 	}
+}
 
-	// Install ripgrep
-	u.PrintSeparator("ripgrep")
-	if err := u.RunCmd("sudo", "apt", "install", "ripgrep", "-y"); err != nil {
+// doctorCommand checks the selected components are in the expected state,
+// exiting non-zero if any check fails.
+func doctorCommand(ctx *steps.Context, registry *steps.Registry, filter steps.Filter) {
+	selection, err := registry.Select(ctx, filter)
+	if err != nil {
 		log.Fatalf("error: %v", err)
 	}
 
-	// Install fzf
-	u.PrintSeparator("fzf")
-	if err := u.RunCmd("sudo", "apt", "install", "fzf", "-y"); err != nil {
-		log.Fatalf("error: %v", err)
+	counts := map[string]int{}
+	for _, result := range steps.Doctor(ctx, selection.Steps()) {
+		fmt.Println(steps.FormatDoctorResult(result))
+		counts[result.Status]++
 	}
 
-	// Install bat
-	u.PrintSeparator("bat")
-	if err := u.RunCmd("sudo", "apt", "install", "bat", "-y"); err != nil {
-		log.Fatalf("error: %v", err)
-	}
-	if err := u.RunCmd("sudo", "ln", "-s", "/usr/bin/batcat", "/usr/bin/bat"); err != nil {
-		log.Errorf("error: %v", err)
+	log.Infof("%d passed, %d warnings, %d failed", counts[steps.ProbePass], counts[steps.ProbeWarn], counts[steps.ProbeFail])
+	if counts[steps.ProbeFail] > 0 {
+		os.Exit(1)
 	}
+}
 
-	// Install lynx
-	u.PrintSeparator("lynx")
-	if err := u.RunCmd("sudo", "apt", "install", "lynx", "-y"); err != nil {
+// logsCommand prints a step's log from a run, or lists the logged steps.
+func logsCommand(args []string) {
+	run, err := state.GetRun(runID)
+	if err != nil {
 		log.Fatalf("error: %v", err)
 	}
 
-	// Install lazygit if not installed
-	u.PrintSeparator("lazygit")
-	if forceInstall || !u.IsExecutableInstalled("lazygit") {
-		if err := u.RunCmd("brew", "install", "lazygit"); err != nil {
+	if len(args) == 0 {
+		entries, err := os.ReadDir(run.Dir)
+		if err != nil {
 			log.Fatalf("error: %v", err)
 		}
+		log.Infof("Logs for run %s:", run.ID)
+		for _, e := range entries {
+			if name, ok := strings.CutSuffix(e.Name(), ".log"); ok {
+				fmt.Println(name)
+			}
+		}
+		return
 	}
 
-	// Install lazydocker if not installed
-	u.PrintSeparator("lazydocker")
-	if forceInstall || !u.IsExecutableInstalled("lazydocker") {
-		if err := u.RunCmd("brew", "install", "lazydocker"); err != nil {
+	for _, step := range args {
+		f, err := os.Open(run.LogPath(step))
+		if err != nil {
+			log.Fatalf("error: no log for %s in run %s", step, run.ID)
+		}
+		if _, err := io.Copy(os.Stdout, f); err != nil {
 			log.Fatalf("error: %v", err)
 		}
+		f.Close()
 	}
+}
 
-	// Install nerd fonts
-	u.PrintSeparator("Nerd Fonts")
-	if err := u.RunCmd("sudo", "apt", "install", "fonts-firacode", "-y"); err != nil {
-		log.Fatalf("error: %v", err)
-	}
-	if err := u.RunCmd("sudo", "apt", "install", "fonts-hack", "-y"); err != nil {
-		log.Fatalf("error: %v", err)
-	}
-	if err := u.UpdateOrCloneRepo("https://github.com/ryanoasis/nerd-fonts", "nerd-fonts"); err != nil {
-		log.Fatalf("error: %v", err)
-	}
-	if err := u.RunCmdInDir("nerd-fonts", "bash", "install.sh"); err != nil {
-		log.Fatalf("error: %v", err)
-	}
-	if err := u.RunCmdInDir("nerd-fonts", "sudo", "bash", "install.sh"); err != nil {
-		log.Fatalf("error: %v", err)
-	}
-	if err := u.RunCmd("gsettings", "set", "org.gnome.desktop.interface", "monospace-font-name", "'FiraMono Nerd Font Medium 13'"); err != nil {
-		log.Fatalf("error: %v", err)
-	}
-
-	// Bun
-	u.PrintSeparator("Bun")
-	if err := u.DownloadFile("https://bun.sh/install", "bun-install.sh"); err != nil {
-		log.Fatalf("error: %v", err)
-	}
-	if err := u.RunCmd("chmod", "+x", "bun-install.sh"); err != nil {
-		log.Fatalf("error: %v", err)
-	}
-	if err := u.RunCmd("./bun-install.sh"); err != nil {
-		log.Fatalf("error: %v", err)
+// savePins records the refs given as <id>@<ref> so later runs and updates
+// keep building from them.
+func savePins(registry *steps.Registry, pins []string) error {
+	st, err := state.Load()
+	if err != nil {
+		return err
 	}
-	if err := u.DeleteFile("bun-install.sh"); err != nil {
-		log.Fatalf("error: %v", err)
+	if st.Pins == nil {
+		st.Pins = map[string]string{}
 	}
 
-	log.Infof("isDesktop: %v", isDesktop)
-
-	// Install desktop environment packages
-	if isDesktop {
-		// Install gnome-tweaks and gnome-shell-extensions
-		u.PrintSeparator("gnome-tweaks and gnome-shell-extensions")
-		if err := u.RunCmd("sudo", "apt", "install", "gnome-tweaks", "gnome-shell-extensions", "-y"); err != nil {
-			log.Fatalf("error: %v", err)
+	for _, pin := range pins {
+		id, ref, ok := strings.Cut(pin, "@")
+		if !ok {
+			return fmt.Errorf("invalid pin %q, expected <id>@<ref>", pin)
 		}
-
-		// Install zen browser
-		u.PrintSeparator("Zen Browser")
-		if err := u.RunCmd("flatpak", "install", "flathub", "io.github.zen_browser.zen", "-y"); err != nil {
-			log.Fatalf("error: %v", err)
+		if _, ok := registry.Get(id); !ok {
+			return fmt.Errorf("unknown step: %s", id)
 		}
-
-		// Install vs*ode
-		if forceInstall || !u.IsExecutableInstalled("code") {
-			u.PrintSeparator("VS C*de")
-			if err := u.DownloadFile("https://code.visualstudio.com/sha/download?build=stable&os=linux-deb-x64", "vscode.deb"); err != nil {
-				log.Fatalf("error: %v", err)
-			}
-			if err := u.RunCmd("sudo", "apt", "install", "./vscode.deb", "-y"); err != nil {
-				log.Fatalf("error: %v", err)
-			}
-			if err := u.DeleteFile("vscode.deb"); err != nil {
-				log.Fatalf("error: %v", err)
-			}
-		}
-
-		// Install postman
-		u.PrintSeparator("Postman")
-		if err := u.DownloadFile("https://dl.pstmn.io/download/latest/linux_64", "postman.tar.gz"); err != nil {
-			log.Fatalf("error: %v", err)
-		}
-		if err := u.RunCmd("sudo", "rm", "-rf", "/usr/bin/postman"); err != nil {
-			log.Fatalf("error: %v", err)
-		}
-		if err := u.RunCmd("sudo", "rm", "-rf", "/opt/Postman"); err != nil {
-			log.Fatalf("error: %v", err)
-		}
-		if err := u.RunCmd("sudo", "tar", "-xzf", "postman.tar.gz", "-C", "/opt"); err != nil {
-
-		}
-		if err := u.RunCmd("sudo", "ln", "-s", "/opt/Postman/Postman", "/usr/bin/postman"); err != nil {
-			log.Fatalf("error: %v", err)
-		}
-		if err := u.DeleteFile("postman.tar.gz"); err != nil {
-			log.Fatalf("error: %v", err)
-		}
-
-		// Install ghostty
-		u.PrintSeparator("Ghostty")
-		if err := u.RunCmd("sudo", "apt", "install", "libgtk-4-dev", "libadwaita-1-dev", "-y"); err != nil {
-			log.Fatalf("error: %v", err)
-		}
-		installedPackages = append(installedPackages, "libgtk-4-dev", "libadwaita-1-dev")
-		if err := u.UpdateOrCloneRepo("https://github.com/ghostty-org/ghostty", "ghostty"); err != nil {
-			log.Fatalf("error: %v", err)
-		}
-		if err := u.RunCmdInDir("ghostty", "sudo", "zig", "build", "-p", "/usr", "-Doptimize=ReleaseFast"); err != nil {
-			log.Fatalf("error: %v", err)
-		}
-		installedPackages = append(installedPackages, "ghostty")
-		// Set CTRL+ALT+T to open ghostty
-		if err := u.RunCmd("gsettings", "set", "org.gnome.settings-daemon.plugins.media-keys.custom-keybinding:/org/gnome/settings-daemon/plugins/media-keys/custom-keybindings/custom0/", "name", "'Open Ghostty'"); err != nil {
-			log.Fatalf("error: %v", err)
-		}
-		if err := u.RunCmd("gsettings", "set", "org.gnome.settings-daemon.plugins.media-keys.custom-keybinding:/org/gnome/settings-daemon/plugins/media-keys/custom-keybindings/custom0/", "binding", "'<Primary><Alt>t'"); err != nil {
-			log.Fatalf("error: %v", err)
-		}
-		if err := u.RunCmd("gsettings", "set", "org.gnome.settings-daemon.plugins.media-keys.custom-keybinding:/org/gnome/settings-daemon/plugins/media-keys/custom-keybindings/custom0/", "command", "'/usr/bin/ghostty'"); err != nil {
-			log.Fatalf("error: %v", err)
-		}
-		// // Scan the ~/.config/ghostty/config and for differences with ghostty-config
-		// ghosttyConfigPath := home + "/.config/ghostty/config"
-		// // Open ./ghostty-config
-		// f, err := os.OpenFile("./ghostty-config", os.O_RDONLY, 0644)
-		// if err != nil {
-		// 	log.Fatalf("error: %v", err)
-		// }
-		// defer f.Close()
-		// log.Infof("Reading %s", f.Name())
-		// // Read the file line by line using scanner
-		// scanner := bufio.NewScanner(f)
-		// for scanner.Scan() {
-		// 	// Read the file line by line
-		// 	line := scanner.Text()
-
-		// 	log.Infof("Line: %s", line)
-
-		// 	// Add the line to ~/.zshrc
-		// 	if err := addIfMissingToFile(ghosttyConfigPath, line); err != nil {
-		// 		log.Fatalf("error: %v", err)
-		// 	}
-		// }
-
-		// Install chrome
-		u.PrintSeparator("Google Chrome")
-		if err := u.DownloadFile("https://dl.google.com/linux/direct/google-chrome-stable_current_amd64.deb", "chrome.deb"); err != nil {
-			log.Fatalf("error: %v", err)
-		}
-		if err := u.RunCmd("sudo", "apt", "install", "./chrome.deb", "-y"); err != nil {
-			log.Fatalf("error: %v", err)
-		}
-		if err := u.DeleteFile("chrome.deb"); err != nil {
-			log.Fatalf("error: %v", err)
-		}
-
-		// Install slack
-		u.PrintSeparator("Slack")
-		if err := u.RunCmd("sudo", "snap", "install", "slack", "--classic"); err != nil {
-			log.Fatalf("error: %v", err)
-		}
-
-		// Install discord
-		u.PrintSeparator("Discord")
-		if err := u.DownloadFile("https://discord.com/api/download?platform=linux&format=deb", "discord.deb"); err != nil {
-			log.Fatalf("error: %v", err)
-		}
-		if err := u.RunCmd("sudo", "apt", "install", "./discord.deb", "-y"); err != nil {
-			log.Fatalf("error: %v", err)
-		}
-
-		// Install steam
-		u.PrintSeparator("Steam")
-		if forceInstall || !u.IsExecutableInstalled("steam") {
-			if err := u.DownloadFile("https://cdn.fastly.steamstatic.com/client/installer/steam.deb", "steam.deb"); err != nil {
-				log.Fatalf("error: %v", err)
-			}
-			if err := u.RunCmd("sudo", "apt", "install", "./steam.deb", "-y"); err != nil {
-				log.Fatalf("error: %v", err)
-			}
-			if err := u.DeleteFile("steam.deb"); err != nil {
-				log.Fatalf("error: %v", err)
-			}
-		}
-
-		// Install sunshine
-		u.PrintSeparator("Sunshine")
-		if err := u.DownloadFile("https://github.com/LizardByte/Sunshine/releases/download/v0.23.1/sunshine-ubuntu-24.04-amd64.deb", "sunshine.deb"); err != nil {
-			log.Fatalf("error: %v", err)
-		}
-		if err := u.RunCmd("sudo", "apt", "install", "./sunshine.deb", "-y"); err != nil {
-			log.Fatalf("error: %v", err)
-		}
-		if err := u.DeleteFile("sunshine.deb"); err != nil {
-			log.Fatalf("error: %v", err)
-		}
-
-		// Install moonlight
-		u.PrintSeparator("Moonlight")
-		if err := u.RunCmd("flatpak", "install", "flathub", "com.moonlight_stream.Moonlight", "-y"); err != nil {
-			log.Fatalf("error: %v", err)
-		}
-
-		// // Check for GoXLR and install GoXLR-Utility
-		// if _, err := os.Stat("/dev/snd/GoXLR"); err == nil {
-		// 	if forceInstall || !isExecutableInstalled("goxlr-utility") {
-		// 		u.PrintSeparator("GoXLR-Utility")
-		// 		if err := downloadFile("https://github.com/GoXLR-on-Linux/goxlr-utility/releases/download/v1.1.4/goxlr-utility_1.1.4-1_amd64.deb", "goxlr-utility.deb"); err != nil {
-		// 			log.Fatalf("error: %v", err)
-		// 		}
-		// 		if err := runCmd("sudo", "apt", "install", "./goxlr-utility.deb", "-y"); err != nil {
-		// 			log.Fatalf("error: %v", err)
-		// 		}
-		// 		if err := deleteFile("goxlr-utility.deb"); err != nil {
-		// 			log.Fatalf("error: %v", err)
-		// 		}
-		// 	}
-		// }
-
-		// Install hyprland
-		u.PrintSeparator("Hyprland")
-		if err := u.RunCmd("sudo", "apt", "install", "hyprland", "hyprland-backgrounds", "wofi", "wofi-pass", "wl-clipboard", "pseudo", "libgtk-4-dev", "waybar", "fonts-font-awesome", "clang-tidy", "gobject-introspection", "libdbusmenu-gtk3-dev", "libevdev-dev", "libfmt-dev", "libgirepository1.0-dev", "libgtk-3-dev", "libgtkmm-3.0-dev", "libinput-dev", "libjsoncpp-dev", "libmpdclient-dev", "libnl-3-dev", "libnl-genl-3-dev", "libpulse-dev", "libsigc++-2.0-dev", "libspdlog-dev", "libwayland-dev", "scdoc", "upower", "libxkbregistry-dev", "sway-notification-center", "light", "-y"); err != nil {
-			log.Fatalf("error: %v", err)
+		if ref == "" {
+			delete(st.Pins, id)
+			log.Infof("Unpinned %s", id)
+		} else {
+			st.Pins[id] = ref
+			log.Infof("Pinned %s to %s", id, ref)
 		}
+	}
+	return st.Save()
+}
 
-		// Install catppuccin cursor
-		u.PrintSeparator("Catppuccin Cursor")
-		if err := u.DownloadFile("https://github.com/catppuccin/cursors/releases/download/v1.0.2/catppuccin-mocha-dark-cursors.zip", "catppuccin-mocha-dark-cursors.zip"); err != nil {
-			log.Fatalf("error: %v", err)
-		}
-		if err := u.RunCmd("sudo", "mkdir", "-p", "/usr/share/icons"); err != nil {
-			log.Fatalf("error: %v", err)
-		}
-		if err := u.RunCmd("sudo", "unzip", "catppuccin-mocha-dark-cursors.zip", "-d", "/usr/share/icons"); err != nil {
-			log.Fatalf("error: %v", err)
-		}
-		if err := u.RunCmd("gsettings", "set", "org.gnome.desktop.interface", "cursor-theme", "'catppuccin-mocha-dark-cursors'"); err != nil {
-			log.Fatalf("error: %v", err)
-		}
-		if err := u.RunCmd("gsettings", "set", "org.gnome.desktop.interface", "cursor-size", "24"); err != nil {
-			log.Fatalf("error: %v", err)
+// parseMirrors reads rewrites given as <url>=<mirror>.
+func parseMirrors(list []string) (map[string]string, error) {
+	mirrors := map[string]string{}
+	for _, m := range list {
+		prefix, mirror, ok := strings.Cut(m, "=")
+		if !ok || prefix == "" || mirror == "" {
+			return nil, fmt.Errorf("invalid git mirror %q, expected <url>=<mirror>", m)
 		}
+		mirrors[prefix] = mirror
+	}
+	return mirrors, nil
+}
 
-		// // Install flameshot
-		// u.PrintSeparator("Flameshot")
-		// if forceInstall || !isExecutableInstalled("flameshot") {
-		// 	if err := runCmd("sudo", "apt", "install", "flameshot", "-y"); err != nil {
-		// 		log.Fatalf("error: %v", err)
-		// 	}
-		// }
-
-		// Install grimblast
-		u.PrintSeparator("Grimblast")
-		if err := u.UpdateOrCloneRepo("git@github.com:hyprwm/contrib", "hyprwm-contrib"); err != nil {
+// parseArgs parses flags that appear anywhere among the positional
+// arguments and returns the positional arguments.
+func parseArgs(fs *flag.FlagSet, args []string) []string {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
 			log.Fatalf("error: %v", err)
 		}
-		if err := u.RunCmdInDir("hyprwm-contrib/grimblast", "sudo", "make", "install"); err != nil {
-			log.Fatalf("error: %v", err)
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional
 		}
-		if err := u.RunCmd("sudo", "apt", "install", "grim", "slurp", "-y"); err != nil {
-			log.Fatalf("error: %v", err)
-		}
-
-		// Install swaybg
-		u.PrintSeparator("swaybg")
-		if err := u.RunCmd("sudo", "apt", "install", "swaybg", "-y"); err != nil {
-			log.Fatalf("error: %v", err)
-		}
-
-		// Install hyprlock
-		// u.PrintSeparator("Hyprlock")
-		// if err := downloadFile("https://github.com/JaKooLit/Ubuntu-Hyprland/raw/refs/heads/24.10/install-scripts/hyprlock.sh", "hyprlock.sh"); err != nil {
-		// 	log.Fatalf("error: %v", err)
-		// }
-		// if err := runCmd("chmod", "+x", "hyprlock.sh"); err != nil {
-		// 	log.Fatalf("error: %v", err)
-		// }
-		// if err := runCmd("./hyprlock.sh"); err != nil {
-		// 	log.Fatalf("error: %v", err)
-		// }
-
+		positional = append(positional, args[0])
+		args = args[1:]
 	}
-
-	log.Info("Bootstrapping complete.")
-	log.Infof("Installed packages: %v", installedPackages)
 }
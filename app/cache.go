@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/charmbracelet/log"
+
+	"github.com/timmo001/bootstrap/state"
+	u "github.com/timmo001/bootstrap/utils"
+)
+
+func gitCacheDir() string {
+	return filepath.Join(state.CacheDir(), "git")
+}
+
+// cacheCommand lists or refreshes the git mirrors clones borrow from.
+func cacheCommand(args []string) {
+	mirrors, err := u.Mirrors(gitCacheDir())
+	if err != nil {
+		log.Fatalf("error: %v", err)
+	}
+	if len(mirrors) == 0 {
+		log.Infof("No mirrors in %s, run with --git-cache to create them", gitCacheDir())
+		return
+	}
+
+	switch {
+	case len(args) == 0 || args[0] == "ls":
+		for _, m := range mirrors {
+			rel, _ := filepath.Rel(gitCacheDir(), m)
+			fmt.Printf("%-16s %s\n", mirrorUpdated(m), rel)
+		}
+	case args[0] == "refresh":
+		failed := 0
+		for _, m := range mirrors {
+			if err := u.RefreshMirror(m); err != nil {
+				log.Errorf("error: %s: %v", m, err)
+				failed++
+			}
+		}
+		if failed > 0 {
+			log.Fatalf("%d of %d mirrors could not be refreshed", failed, len(mirrors))
+		}
+		log.Infof("Refreshed %d mirrors", len(mirrors))
+	default:
+		log.Fatalf("unknown cache command: %s", args[0])
+	}
+}
+
+// mirrorUpdated returns when the mirror was last fetched.
+func mirrorUpdated(dir string) string {
+	for _, name := range []string{"FETCH_HEAD", "HEAD"} {
+		if info, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return info.ModTime().Format("2006-01-02 15:04")
+		}
+	}
+	return "unknown"
+}
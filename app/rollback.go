@@ -0,0 +1,36 @@
+package main
+
+import (
+	"github.com/charmbracelet/log"
+
+	"github.com/timmo001/bootstrap/state"
+	"github.com/timmo001/bootstrap/steps"
+	u "github.com/timmo001/bootstrap/utils"
+)
+
+// rollbackCommand uninstalls whatever the given run installed.
+func rollbackCommand(ctx *steps.Context, registry *steps.Registry, args []string) {
+	if len(args) != 1 {
+		log.Fatal("usage: bootstrap rollback <run-id>")
+	}
+	run, err := state.GetRun(args[0])
+	if err != nil {
+		log.Fatalf("error: %v", err)
+	}
+	report, err := steps.ReadReport(run.Dir)
+	if err != nil {
+		log.Fatalf("error: %v", err)
+	}
+
+	log.Infof("Rolling back run %s...", run.ID)
+
+	selection := registry.SelectRollback(report)
+	u.PrintSeparator("Selecting components to uninstall")
+	selection.Print()
+
+	if err := execute(ctx, selection, selection.Uninstalls()); err != nil {
+		log.Fatalf("error: %v", err)
+	}
+
+	log.Info("Rollback complete.")
+}
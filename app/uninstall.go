@@ -0,0 +1,26 @@
+package main
+
+import (
+	"github.com/charmbracelet/log"
+
+	"github.com/timmo001/bootstrap/steps"
+	u "github.com/timmo001/bootstrap/utils"
+)
+
+// uninstallCommand removes the named components.
+func uninstallCommand(ctx *steps.Context, registry *steps.Registry, ids []string) {
+	log.Info("Uninstalling...")
+
+	selection, err := registry.SelectUninstalls(ctx, ids)
+	if err != nil {
+		log.Fatalf("error: %v", err)
+	}
+	u.PrintSeparator("Selecting components to uninstall")
+	selection.Print()
+
+	if err := execute(ctx, selection, selection.Uninstalls()); err != nil {
+		log.Fatalf("error: %v", err)
+	}
+
+	log.Info("Uninstalling complete.")
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"github.com/charmbracelet/log"
+
+	"github.com/timmo001/bootstrap/steps"
+	u "github.com/timmo001/bootstrap/utils"
+)
+
+// updateCommand runs the update path of the named components, or of every
+// installed component that has one.
+func updateCommand(ctx *steps.Context, registry *steps.Registry, ids []string) {
+	log.Info("Updating...")
+
+	selection, err := registry.SelectUpdates(ctx, ids)
+	if err != nil {
+		log.Fatalf("error: %v", err)
+	}
+	u.PrintSeparator("Selecting components to update")
+	selection.Print()
+
+	if err := execute(ctx, selection, selection.Updates()); err != nil {
+		log.Fatalf("error: %v", err)
+	}
+
+	log.Info("Updating complete.")
+}
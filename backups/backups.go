@@ -0,0 +1,223 @@
+package backups
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/log"
+
+	"github.com/timmo001/bootstrap/state"
+)
+
+const (
+	KindFile    = "file"
+	KindSymlink = "symlink"
+	// KindMissing records that the path didn't exist, so restoring removes it
+	KindMissing = "missing"
+)
+
+// Entry is a file as it was before bootstrap first changed it in a run.
+type Entry struct {
+	Path string      `json:"path"`
+	Kind string      `json:"kind"`
+	Mode os.FileMode `json:"mode,omitempty"`
+	// Link is the target of a symlink
+	Link string    `json:"link,omitempty"`
+	Time time.Time `json:"time"`
+	// RunID is filled in when listing
+	RunID string `json:"-"`
+}
+
+var (
+	mu    sync.Mutex
+	runID string
+	saved = map[string]bool{}
+)
+
+// SetRun files the backups taken from now on under the run.
+func SetRun(id string) {
+	mu.Lock()
+	defer mu.Unlock()
+	runID = id
+	saved = map[string]bool{}
+}
+
+// Dir returns the backup store in the state directory.
+func Dir() string {
+	return filepath.Join(state.Dir(), "backups")
+}
+
+// Save snapshots path before it is overwritten, once per run. Later saves
+// in the same run would only capture bootstrap's own changes.
+func Save(path string) error {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if runID == "" {
+		runID = time.Now().Format("20060102-150405")
+	}
+	if saved[path] {
+		return nil
+	}
+
+	e := Entry{Path: path, Time: time.Now()}
+	info, err := os.Lstat(path)
+	switch {
+	case os.IsNotExist(err):
+		e.Kind = KindMissing
+	case err != nil:
+		return err
+	case info.Mode()&os.ModeSymlink != 0:
+		e.Kind = KindSymlink
+		if e.Link, err = os.Readlink(path); err != nil {
+			return err
+		}
+	case info.Mode().IsRegular():
+		e.Kind, e.Mode = KindFile, info.Mode().Perm()
+		if err := copyFile(path, filePath(runID, path)); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("can't back up %s, it is not a file", path)
+	}
+
+	entries, err := readIndex(runID)
+	if err != nil {
+		return err
+	}
+	if err := writeIndex(runID, append(entries, e)); err != nil {
+		return err
+	}
+	saved[path] = true
+	if e.Kind != KindMissing {
+		log.Infof("Backed up %s", path)
+	}
+	return nil
+}
+
+// List returns every backup, oldest run first.
+func List() ([]Entry, error) {
+	runs, err := os.ReadDir(Dir())
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var all []Entry
+	for _, run := range runs {
+		entries, err := readIndex(run.Name())
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			e.RunID = run.Name()
+			all = append(all, e)
+		}
+	}
+	sort.SliceStable(all, func(i, j int) bool { return all[i].RunID < all[j].RunID })
+	return all, nil
+}
+
+// Restore puts path back as it was backed up in the run, or in the oldest
+// run that has it if run is empty, which is the file from before bootstrap
+// first changed it. The current content is replaced without a backup, so
+// restoring again gives the same result.
+func Restore(path, run string) (*Entry, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	all, err := List()
+	if err != nil {
+		return nil, err
+	}
+	var found *Entry
+	for i := range all {
+		if all[i].Path == path && (run == "" || all[i].RunID == run) {
+			found = &all[i]
+			break
+		}
+	}
+	if found == nil {
+		if run != "" {
+			return nil, fmt.Errorf("no backup of %s in run %s", path, run)
+		}
+		return nil, fmt.Errorf("no backup of %s", path)
+	}
+
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	switch found.Kind {
+	case KindFile:
+		if err := copyFile(filePath(found.RunID, path), path); err != nil {
+			return nil, err
+		}
+		err = os.Chmod(path, found.Mode)
+	case KindSymlink:
+		err = os.Symlink(found.Link, path)
+	}
+	return found, err
+}
+
+func runDir(run string) string {
+	return filepath.Join(Dir(), run)
+}
+
+// filePath mirrors the original path under the run's files directory.
+func filePath(run, path string) string {
+	return filepath.Join(runDir(run), "files", path)
+}
+
+func readIndex(run string) ([]Entry, error) {
+	data, err := os.ReadFile(filepath.Join(runDir(run), "index.json"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var entries []Entry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func writeIndex(run string, entries []Entry) error {
+	if err := os.MkdirAll(runDir(run), 0700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	index := filepath.Join(runDir(run), "index.json")
+	if err := os.WriteFile(index+".tmp", append(data, '\n'), 0600); err != nil {
+		return err
+	}
+	return os.Rename(index+".tmp", index)
+}
+
+func copyFile(src, dst string) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(dst, data, 0600)
+}
@@ -0,0 +1,103 @@
+package backups
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRestore(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	file := filepath.Join(t.TempDir(), "rc")
+
+	// Three runs each back up the file before changing it
+	versions := []string{"original", "first run", "second run"}
+	for i, content := range versions {
+		if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if i == len(versions)-1 {
+			break
+		}
+		SetRun(fmt.Sprintf("2026010%d-000000", i+1))
+		if err := Save(file); err != nil {
+			t.Fatal(err)
+		}
+	}
+	runs, _ := os.ReadDir(Dir())
+
+	tests := []struct {
+		name string
+		run  string
+		want string
+	}{
+		{name: "oldest by default", want: "original"},
+		{name: "again is the same", want: "original"},
+		{name: "named run", run: "20260102-000000", want: "first run"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := Restore(file, tt.run)
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("restored %q from %s, want %q", data, e.RunID, tt.want)
+			}
+		})
+	}
+
+	if after, _ := os.ReadDir(Dir()); len(after) != len(runs) {
+		t.Errorf("restoring added backup runs: %d before, %d after", len(runs), len(after))
+	}
+	if _, err := Restore(file, "20990101-000000"); err == nil {
+		t.Error("restoring from a run without the file succeeded")
+	}
+}
+
+func TestSaveMissingAndSymlink(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	dir := t.TempDir()
+	missing := filepath.Join(dir, "missing")
+	link := filepath.Join(dir, "link")
+	if err := os.Symlink("target", link); err != nil {
+		t.Fatal(err)
+	}
+
+	SetRun("20260101-000000")
+	if err := Save(missing); err != nil {
+		t.Fatal(err)
+	}
+	if err := Save(link); err != nil {
+		t.Fatal(err)
+	}
+	if err := Save(dir); err == nil {
+		t.Error("backing up a directory succeeded")
+	}
+
+	// Bootstrap creates the missing file and replaces the link
+	if err := os.WriteFile(missing, []byte("new"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(link); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Restore(missing, ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Lstat(missing); !os.IsNotExist(err) {
+		t.Errorf("restoring a missing file left it in place: %v", err)
+	}
+	if _, err := Restore(link, ""); err != nil {
+		t.Fatal(err)
+	}
+	if target, err := os.Readlink(link); err != nil || target != "target" {
+		t.Errorf("link = %q, %v, want target", target, err)
+	}
+}
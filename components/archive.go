@@ -0,0 +1,300 @@
+package components
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/charmbracelet/log"
+
+	"github.com/timmo001/bootstrap/state"
+	"github.com/timmo001/bootstrap/steps"
+	u "github.com/timmo001/bootstrap/utils"
+)
+
+// Where archive apps, their commands and their launchers go
+var (
+	appsDir     = "/opt"
+	appBinDir   = "/usr/local/bin"
+	launcherDir = "/usr/share/applications"
+)
+
+// archiveApp is an application the vendor ships as a tarball instead of a
+// package. Each version is extracted to /opt/<Name>-<version>, and
+// /opt/<Name>-current links to the one in use. A forced reinstall of the
+// same version goes to /opt/<Name>-<version>-<time>, so the link is only
+// ever switched between complete directories.
+type archiveApp struct {
+	Name string
+	URL  string
+	// Strip is the number of leading directories to drop from the archive
+	Strip int
+	// Binary and Icon are paths inside the extracted archive
+	Binary string
+	Icon   string
+	// Version reads the version from the extracted archive
+	Version func(dir string) (string, error)
+	// Title, Comment and Categories go in the .desktop file
+	Title      string
+	Comment    string
+	Categories string
+	// Legacy are paths from earlier installs, removed once this one is in
+	// place
+	Legacy []string
+}
+
+// fromArchive makes s install and update the app from its archive.
+// Installed, Version and Uninstall default to the app's current version.
+func fromArchive(s *steps.Step, a archiveApp) *steps.Step {
+	s.Run = a.install
+	s.Update = a.install
+	s.Plan = func(ctx *steps.Context) ([]string, error) {
+		if version := a.installedVersion(); version != "" {
+			return []string{fmt.Sprintf("download %s if it changed, and switch %s to it if it is newer than %s", a.URL, a.current(), version)}, nil
+		}
+		return []string{fmt.Sprintf("install %s from %s into %s/%s-<version>", a.Title, a.URL, appsDir, a.Name)}, nil
+	}
+	if s.Installed == nil {
+		s.Installed = func(ctx *steps.Context) bool {
+			return a.installedVersion() != "" && u.ExistsPath(a.bin())
+		}
+	}
+	if s.Version == nil {
+		s.Version = func(ctx *steps.Context) string {
+			return a.installedVersion()
+		}
+	}
+	if s.Uninstall == nil {
+		s.Uninstall = a.remove
+	}
+	return s
+}
+
+func (a archiveApp) current() string {
+	return filepath.Join(appsDir, a.Name+"-current")
+}
+
+func (a archiveApp) bin() string {
+	return filepath.Join(appBinDir, a.Name)
+}
+
+func (a archiveApp) launcher() string {
+	return filepath.Join(launcherDir, a.Name+".desktop")
+}
+
+// installedVersion returns the version current links to, or "".
+func (a archiveApp) installedVersion() string {
+	target, err := os.Readlink(a.current())
+	if err != nil || !u.ExistsPath(target) {
+		return ""
+	}
+	version, _ := a.Version(target)
+	return version
+}
+
+// versions returns the extracted versions, skipping other apps whose names
+// start with this one's.
+func (a archiveApp) versions() ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(appsDir, a.Name+"-*"))
+	if err != nil {
+		return nil, err
+	}
+	var dirs []string
+	for _, dir := range matches {
+		version := strings.TrimPrefix(filepath.Base(dir), a.Name+"-")
+		if version != "" && unicode.IsDigit(rune(version[0])) {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs, nil
+}
+
+func (a archiveApp) install(ctx *steps.Context) error {
+	tmp, err := os.MkdirTemp("", a.Name+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	st, err := state.Load()
+	if err != nil {
+		return err
+	}
+	installed := a.installedVersion()
+
+	// Skip the download when the URL still serves what was installed
+	fingerprint, err := u.RemoteFingerprint(a.URL)
+	if err != nil {
+		log.Warnf("Can't check %s for changes: %v", a.URL, err)
+	}
+	last := st.Archives[a.Name]
+	if fingerprint != "" && last != nil && last.URL == a.URL && last.Fingerprint == fingerprint && last.Version == installed && !ctx.Force {
+		log.Infof("%s %s is up to date", a.Name, installed)
+		return a.link(ctx, tmp)
+	}
+
+	file := filepath.Join(tmp, a.Name+".tar")
+	if err := u.DownloadFile(a.URL, file); err != nil {
+		return err
+	}
+
+	// Extract next to the versions, so moving it into place is a rename
+	staging := filepath.Join(appsDir, "."+a.Name+"-new")
+	if err := u.RunCmd("sudo", "rm", "-rf", staging); err != nil {
+		return err
+	}
+	if err := u.RunCmd("sudo", "mkdir", "-p", staging); err != nil {
+		return err
+	}
+	defer u.RunCmd("sudo", "rm", "-rf", staging)
+	if err := u.RunCmd("sudo", "tar", "-xf", file, "-C", staging, "--no-same-owner", fmt.Sprintf("--strip-components=%d", a.Strip)); err != nil {
+		return err
+	}
+	version, err := a.Version(staging)
+	if err != nil {
+		return err
+	}
+	if version == "" {
+		return fmt.Errorf("no version in %s", a.URL)
+	}
+
+	dir, _ := filepath.EvalSymlinks(a.current())
+	switch {
+	case installed == version && !ctx.Force:
+		log.Infof("%s %s is up to date", a.Name, version)
+	case installed != "" && u.CompareVersions(version, installed) < 0 && !ctx.Force:
+		log.Infof("%s %s is newer than %s, use --force to downgrade", a.Name, installed, version)
+		return nil
+	default:
+		dir = filepath.Join(appsDir, a.Name+"-"+version)
+		if u.ExistsPath(dir) {
+			// In use or left over, either way not to be touched until current
+			// points elsewhere
+			dir = fmt.Sprintf("%s-%d", dir, time.Now().Unix())
+		}
+		if err := u.RunCmd("sudo", "mv", "-T", staging, dir); err != nil {
+			return err
+		}
+		// Swap the link with a rename, so current is never missing
+		if err := u.RunCmd("sudo", "ln", "-sfn", dir, a.current()+".new"); err != nil {
+			return err
+		}
+		if err := u.RunCmd("sudo", "mv", "-T", a.current()+".new", a.current()); err != nil {
+			return err
+		}
+		if installed == "" {
+			ctx.Change(fmt.Sprintf("installed %s %s", a.Name, version))
+		} else {
+			ctx.Change(fmt.Sprintf("updated %s %s → %s", a.Name, installed, version))
+		}
+	}
+
+	if err := a.link(ctx, tmp); err != nil {
+		return err
+	}
+	if err := a.prune(dir); err != nil {
+		return err
+	}
+
+	if st.Archives == nil {
+		st.Archives = map[string]*state.Archive{}
+	}
+	st.Archives[a.Name] = &state.Archive{URL: a.URL, Fingerprint: fingerprint, Version: version, Installed: time.Now()}
+	return st.Save()
+}
+
+// link points the command and the launcher at the current version.
+func (a archiveApp) link(ctx *steps.Context, tmp string) error {
+	binary := filepath.Join(a.current(), a.Binary)
+	if target, _ := os.Readlink(a.bin()); target != binary {
+		if err := u.RunCmd("sudo", "mkdir", "-p", appBinDir); err != nil {
+			return err
+		}
+		if err := u.RunCmd("sudo", "ln", "-sfn", binary, a.bin()); err != nil {
+			return err
+		}
+		ctx.Change("linked " + a.bin())
+	}
+
+	entry := strings.Join([]string{
+		"[Desktop Entry]",
+		"Type=Application",
+		"Name=" + a.Title,
+		"Comment=" + a.Comment,
+		"Exec=" + a.bin(),
+		"Icon=" + filepath.Join(a.current(), a.Icon),
+		"Categories=" + a.Categories,
+		"Terminal=false",
+		"",
+	}, "\n")
+	if current, err := os.ReadFile(a.launcher()); err == nil && string(current) == entry {
+		return nil
+	}
+	file := filepath.Join(tmp, a.Name+".desktop")
+	if err := os.WriteFile(file, []byte(entry), 0644); err != nil {
+		return err
+	}
+	if err := u.RunCmd("sudo", "install", "-D", "-m", "644", file, a.launcher()); err != nil {
+		return err
+	}
+	ctx.Change("wrote " + a.launcher())
+	return nil
+}
+
+// prune deletes every version but keep, and the paths of earlier installs.
+func (a archiveApp) prune(keep string) error {
+	dirs, err := a.versions()
+	if err != nil {
+		return err
+	}
+	var old []string
+	for _, dir := range append(dirs, a.Legacy...) {
+		if _, err := os.Lstat(dir); err == nil && dir != keep {
+			old = append(old, dir)
+		}
+	}
+	if len(old) == 0 {
+		return nil
+	}
+	return u.RunCmd("sudo", append([]string{"rm", "-rf"}, old...)...)
+}
+
+func (a archiveApp) remove(ctx *steps.Context) error {
+	dirs, err := a.versions()
+	if err != nil {
+		return err
+	}
+	paths := append(dirs, a.current(), a.bin(), a.launcher())
+	if err := removePaths(append(paths, a.Legacy...)...)(ctx); err != nil {
+		return err
+	}
+
+	st, err := state.Load()
+	if err != nil {
+		return err
+	}
+	delete(st.Archives, a.Name)
+	return st.Save()
+}
+
+// jsonVersion reads the version field of a JSON file in the archive, such
+// as an Electron app's package.json.
+func jsonVersion(file string) func(dir string) (string, error) {
+	return func(dir string) (string, error) {
+		data, err := os.ReadFile(filepath.Join(dir, file))
+		if err != nil {
+			return "", err
+		}
+		var manifest struct {
+			Version string `json:"version"`
+		}
+		if err := json.Unmarshal(data, &manifest); err != nil {
+			return "", fmt.Errorf("%s: %w", file, err)
+		}
+		return manifest.Version, nil
+	}
+}
@@ -0,0 +1,155 @@
+package components
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/timmo001/bootstrap/steps"
+	u "github.com/timmo001/bootstrap/utils"
+)
+
+// Register adds every component to the registry in run order.
+func Register(r *steps.Registry) {
+	r.Add(inCategory(steps.CategorySystem, systemSteps())...)
+	r.Add(inCategory(steps.CategoryShell, shellSteps())...)
+	r.Add(inCategory(steps.CategoryLanguages, languageSteps())...)
+	r.Add(inCategory(steps.CategoryCLI, toolSteps())...)
+	r.Add(inCategory(steps.CategoryDesktop, desktopSteps())...)
+	r.Add(inCategory(steps.CategoryGaming, gamingSteps())...)
+	r.Add(inCategory(steps.CategoryHyprland, hyprlandSteps())...)
+}
+
+func desktopOnly(ctx *steps.Context) string {
+	if !ctx.IsDesktop {
+		return "not running on a desktop environment"
+	}
+	return ""
+}
+
+func notWSL(ctx *steps.Context) string {
+	if ctx.IsWSL {
+		return "not supported on WSL"
+	}
+	return ""
+}
+
+// aptInstall installs the package if its executable is missing, or always when forced.
+func aptInstall(ctx *steps.Context, pkg string) error {
+	if ctx.Force || !u.IsExecutableInstalled(pkg) {
+		return u.RunCmd("sudo", "apt", "install", pkg, "-y")
+	}
+	return nil
+}
+
+func inCategory(category string, s []*steps.Step) []*steps.Step {
+	for _, step := range s {
+		step.Category = category
+	}
+	return s
+}
+
+func executable(name string) func(*steps.Context) bool {
+	return func(ctx *steps.Context) bool {
+		return u.IsExecutableInstalled(name)
+	}
+}
+
+func homePath(path string) func(*steps.Context) bool {
+	return func(ctx *steps.Context) bool {
+		return u.ExistsPath(ctx.Home + "/" + path)
+	}
+}
+
+// repoCommit reports the commit a checkout under the home directory is at.
+func repoCommit(path string) func(*steps.Context) string {
+	return func(ctx *steps.Context) string {
+		commit, err := u.RepoHead(ctx.Home + "/" + path)
+		if err != nil {
+			return ""
+		}
+		return u.ShortCommit(commit)
+	}
+}
+
+func existsPath(path string) func(*steps.Context) bool {
+	return func(ctx *steps.Context) bool {
+		return u.ExistsPath(path)
+	}
+}
+
+func aptPackage(name string) func(*steps.Context) bool {
+	return func(ctx *steps.Context) bool {
+		status, err := u.CmdOutput("dpkg-query", "-W", "-f=${Status}", name)
+		return err == nil && status == "install ok installed"
+	}
+}
+
+func flatpakApp(id string) func(*steps.Context) bool {
+	return func(ctx *steps.Context) bool {
+		_, err := u.CmdOutput("flatpak", "info", id)
+		return err == nil
+	}
+}
+
+func snapPackage(name string) func(*steps.Context) bool {
+	return func(ctx *steps.Context) bool {
+		_, err := u.CmdOutput("snap", "list", name)
+		return err == nil
+	}
+}
+
+var versionPattern = regexp.MustCompile(`\d+\.\d+(\.\d+)*([-+~][0-9A-Za-z.+~-]+)?`)
+
+// commandVersion runs the command and picks the first version number out of its output.
+func commandVersion(name string, arg ...string) func(*steps.Context) string {
+	return func(ctx *steps.Context) string {
+		out, err := u.CmdOutput(name, arg...)
+		if err != nil {
+			return ""
+		}
+		return versionPattern.FindString(out)
+	}
+}
+
+func aptVersion(name string) func(*steps.Context) string {
+	return func(ctx *steps.Context) string {
+		version, err := u.CmdOutput("dpkg-query", "-W", "-f=${Version}", name)
+		if err != nil {
+			return ""
+		}
+		return version
+	}
+}
+
+func flatpakVersion(id string) func(*steps.Context) string {
+	return func(ctx *steps.Context) string {
+		out, err := u.CmdOutput("flatpak", "info", id)
+		if err != nil {
+			return ""
+		}
+		for _, line := range strings.Split(out, "\n") {
+			if version, ok := strings.CutPrefix(strings.TrimSpace(line), "Version:"); ok {
+				return strings.TrimSpace(version)
+			}
+		}
+		return ""
+	}
+}
+
+func snapVersion(name string) func(*steps.Context) string {
+	return func(ctx *steps.Context) string {
+		out, err := u.CmdOutput("snap", "list", name)
+		if err != nil {
+			return ""
+		}
+		// Skip the header row, the version is the second column
+		lines := strings.Split(out, "\n")
+		if len(lines) < 2 {
+			return ""
+		}
+		if fields := strings.Fields(lines[1]); len(fields) > 1 {
+			return fields[1]
+		}
+		return ""
+	}
+}
@@ -0,0 +1,101 @@
+package components
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/timmo001/bootstrap/steps"
+)
+
+// testHome returns a context with a temporary home, and keeps state,
+// backups and config out of the real one.
+func testHome(t *testing.T) *steps.Context {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_STATE_HOME", filepath.Join(home, ".local", "state"))
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	return &steps.Context{Home: home, Name: "Test User", Email: "test@example.com"}
+}
+
+func git(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-c", "user.name=t", "-c", "user.email=t@example.com", "-c", "commit.gpgsign=false"}, args...)...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func writeFile(t *testing.T, file, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// fakeSudo puts a sudo on PATH that runs the command as the current user.
+func fakeSudo(t *testing.T) {
+	t.Helper()
+	bin := t.TempDir()
+	writeFile(t, filepath.Join(bin, "sudo"), "#!/bin/sh\nexec \"$@\"\n")
+	if err := os.Chmod(filepath.Join(bin, "sudo"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+":"+os.Getenv("PATH"))
+}
+
+// component returns the registered step with the id.
+func component(t *testing.T, id string) *steps.Step {
+	t.Helper()
+	r := steps.NewRegistry()
+	Register(r)
+	s, ok := r.Get(id)
+	if !ok {
+		t.Fatalf("no component %s", id)
+	}
+	return s
+}
+
+// runTwice runs the step the way two bootstrap runs in a row would. Both
+// have to succeed and leave it installed, and the second mustn't change
+// anything. check is called after each run with the run's index, to look
+// at its warnings and changes.
+func runTwice(t *testing.T, ctx *steps.Context, s *steps.Step, check func(run int)) {
+	t.Helper()
+	for run := 0; run < 2; run++ {
+		ctx.Warnings, ctx.Changes = nil, nil
+		if err := s.Run(ctx); err != nil {
+			t.Fatalf("run %d: %v", run, err)
+		}
+		if run > 0 && len(ctx.Changes) > 0 {
+			t.Errorf("run %d: changes = %q, want none", run, ctx.Changes)
+		}
+		if s.Installed != nil && !s.Installed(ctx) {
+			t.Errorf("run %d: %s isn't installed after running", run, s.ID)
+		}
+		if check != nil {
+			check(run)
+		}
+	}
+}
+
+// uninstall removes the step and checks it no longer reports as installed.
+func uninstall(t *testing.T, ctx *steps.Context, s *steps.Step) {
+	t.Helper()
+	if err := s.Uninstall(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if s.Installed(ctx) {
+		t.Errorf("%s is still installed after uninstalling", s.ID)
+	}
+}
@@ -0,0 +1,109 @@
+package components
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/charmbracelet/log"
+
+	"github.com/timmo001/bootstrap/steps"
+	u "github.com/timmo001/bootstrap/utils"
+)
+
+// vendorDeb is a package a vendor publishes as a .deb at a fixed URL that
+// always serves the latest version.
+type vendorDeb struct {
+	URL string
+	// Package is the name the .deb installs, checked against the download
+	Package string
+}
+
+// fromDeb makes s download the .deb on install and update, and install it
+// only if it is newer than the installed version. Installed, Version and
+// Uninstall default to the package's dpkg status.
+func fromDeb(s *steps.Step, d vendorDeb) *steps.Step {
+	s.Run = d.install
+	s.Update = d.install
+	s.Plan = func(ctx *steps.Context) ([]string, error) {
+		if installed := installedDebVersion(d.Package); installed != "" {
+			return []string{fmt.Sprintf("download %s and install it if newer than %s", d.URL, installed)}, nil
+		}
+		return []string{"install " + d.Package + " from " + d.URL}, nil
+	}
+	if s.Installed == nil {
+		s.Installed = aptPackage(d.Package)
+	}
+	if s.Version == nil {
+		s.Version = aptVersion(d.Package)
+	}
+	if s.Uninstall == nil {
+		s.Uninstall = aptPurge(d.Package)
+	}
+	return s
+}
+
+func (d vendorDeb) install(ctx *steps.Context) error {
+	tmp, err := os.MkdirTemp("", d.Package+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	file := filepath.Join(tmp, d.Package+".deb")
+	if err := u.DownloadFile(d.URL, file); err != nil {
+		return err
+	}
+	return installDeb(ctx, file, d.Package)
+}
+
+// installDeb installs a downloaded .deb of pkg unless the same or a newer
+// version is already installed.
+func installDeb(ctx *steps.Context, file, pkg string) error {
+	name, err := u.CmdOutput("dpkg-deb", "-f", file, "Package")
+	if err != nil {
+		return err
+	}
+	if name != pkg {
+		return fmt.Errorf("%s is a package of %s, not %s", filepath.Base(file), name, pkg)
+	}
+	available, err := u.CmdOutput("dpkg-deb", "-f", file, "Version")
+	if err != nil {
+		return err
+	}
+
+	installed := installedDebVersion(pkg)
+	if installed != "" && !ctx.Force {
+		if _, err := u.CmdOutput("dpkg", "--compare-versions", available, "gt", installed); err != nil {
+			if available == installed {
+				log.Infof("%s %s is up to date", pkg, installed)
+			} else {
+				log.Infof("%s %s is newer than %s, use --force to downgrade", pkg, installed, available)
+			}
+			return nil
+		}
+	}
+
+	// apt reads the file as the _apt user, which can't get into temp dirs
+	if err := os.Chmod(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	if installed == "" {
+		log.Infof("Installing %s %s", pkg, available)
+	} else {
+		log.Infof("Updating %s %s → %s", pkg, installed, available)
+	}
+	if err := u.RunCmd("sudo", "apt", "install", file, "-y"); err != nil {
+		return err
+	}
+	ctx.Change(fmt.Sprintf("installed %s %s", pkg, available))
+	return nil
+}
+
+// installedDebVersion returns the installed version of the package, or "".
+func installedDebVersion(pkg string) string {
+	if !aptPackage(pkg)(nil) {
+		return ""
+	}
+	return aptVersion(pkg)(nil)
+}
@@ -0,0 +1,131 @@
+package components
+
+import (
+	"github.com/timmo001/bootstrap/steps"
+	u "github.com/timmo001/bootstrap/utils"
+)
+
+func desktopSteps() []*steps.Step {
+	return []*steps.Step{
+		{
+			ID:        "gnome-tweaks",
+			Title:     "gnome-tweaks and gnome-shell-extensions",
+			Tags:      []string{"desktop"},
+			Requires:  []string{"apt-update"},
+			SkipIf:    desktopOnly,
+			Installed: aptPackage("gnome-tweaks"),
+			Version:   aptVersion("gnome-tweaks"),
+			Update:    aptUpgrade("gnome-tweaks", "gnome-shell-extensions"),
+			Uninstall: aptPurge("gnome-tweaks", "gnome-shell-extensions"),
+			Run: func(ctx *steps.Context) error {
+				return u.RunCmd("sudo", "apt", "install", "gnome-tweaks", "gnome-shell-extensions", "-y")
+			},
+		},
+		{
+			ID:        "zen-browser",
+			Title:     "Zen Browser",
+			Tags:      []string{"desktop"},
+			Requires:  []string{"flatpak"},
+			SkipIf:    desktopOnly,
+			Installed: flatpakApp("io.github.zen_browser.zen"),
+			Version:   flatpakVersion("io.github.zen_browser.zen"),
+			Update:    flatpakUpdate("io.github.zen_browser.zen"),
+			Uninstall: flatpakUninstall("io.github.zen_browser.zen"),
+			Run: func(ctx *steps.Context) error {
+				return u.RunCmd("flatpak", "install", "flathub", "io.github.zen_browser.zen", "-y")
+			},
+		},
+		fromDeb(&steps.Step{
+			ID:        "vscode",
+			Title:     "VS C*de",
+			Tags:      []string{"desktop", "dev"},
+			Requires:  []string{"curl"},
+			SkipIf:    desktopOnly,
+			Installed: executable("code"),
+			Version:   commandVersion("code", "--version"),
+		}, vendorDeb{
+			URL:     "https://code.visualstudio.com/sha/download?build=stable&os=linux-deb-x64",
+			Package: "code",
+		}),
+		fromArchive(&steps.Step{
+			ID:       "postman",
+			Title:    "Postman",
+			Tags:     []string{"desktop", "dev"},
+			Requires: []string{"curl"},
+			SkipIf:   desktopOnly,
+		}, archiveApp{
+			Name:       "postman",
+			URL:        "https://dl.pstmn.io/download/latest/linux_64",
+			Strip:      1,
+			Binary:     "Postman",
+			Icon:       "app/resources/app/assets/icon.png",
+			Version:    jsonVersion("app/resources/app/package.json"),
+			Title:      "Postman",
+			Comment:    "API platform for building and using APIs",
+			Categories: "Development;",
+			Legacy:     []string{"/opt/Postman", "/usr/bin/postman"},
+		}),
+		fromSource(&steps.Step{
+			ID:        "ghostty",
+			Title:     "Ghostty",
+			Tags:      []string{"desktop"},
+			Requires:  []string{"apt-update", "git", "zig"},
+			SkipIf:    desktopOnly,
+			Installed: executable("ghostty"),
+			Version:   commandVersion("ghostty", "--version"),
+			Uninstall: resetSettings("ghostty"),
+		}, sourceBuild{
+			Repo:    "https://github.com/ghostty-org/ghostty",
+			Dir:     "ghostty",
+			Prefix:  "/usr",
+			Deps:    []string{"libgtk-4-dev", "libadwaita-1-dev"},
+			Install: [][]string{{"sudo", "zig", "build", "-p", "{destdir}{prefix}", "-Doptimize=ReleaseFast"}},
+			Staged:  true,
+		}, func(ctx *steps.Context) error {
+			// Set CTRL+ALT+T to open ghostty
+			if err := gsettingsSet("ghostty", "org.gnome.settings-daemon.plugins.media-keys.custom-keybinding:/org/gnome/settings-daemon/plugins/media-keys/custom-keybindings/custom0/", "name", "'Open Ghostty'"); err != nil {
+				return err
+			}
+			if err := gsettingsSet("ghostty", "org.gnome.settings-daemon.plugins.media-keys.custom-keybinding:/org/gnome/settings-daemon/plugins/media-keys/custom-keybindings/custom0/", "binding", "'<Primary><Alt>t'"); err != nil {
+				return err
+			}
+			return gsettingsSet("ghostty", "org.gnome.settings-daemon.plugins.media-keys.custom-keybinding:/org/gnome/settings-daemon/plugins/media-keys/custom-keybindings/custom0/", "command", "'/usr/bin/ghostty'")
+		}),
+		fromDeb(&steps.Step{
+			ID:        "chrome",
+			Title:     "Google Chrome",
+			Tags:      []string{"desktop"},
+			Requires:  []string{"curl"},
+			SkipIf:    desktopOnly,
+			Installed: executable("google-chrome"),
+			Version:   commandVersion("google-chrome", "--version"),
+		}, vendorDeb{
+			URL:     "https://dl.google.com/linux/direct/google-chrome-stable_current_amd64.deb",
+			Package: "google-chrome-stable",
+		}),
+		{
+			ID:        "slack",
+			Title:     "Slack",
+			Tags:      []string{"desktop"},
+			SkipIf:    desktopOnly,
+			Installed: snapPackage("slack"),
+			Version:   snapVersion("slack"),
+			Update:    snapRefresh("slack"),
+			Uninstall: snapRemove("slack"),
+			Run: func(ctx *steps.Context) error {
+				return u.RunCmd("sudo", "snap", "install", "slack", "--classic")
+			},
+		},
+		fromDeb(&steps.Step{
+			ID:        "discord",
+			Title:     "Discord",
+			Tags:      []string{"desktop"},
+			Requires:  []string{"curl"},
+			SkipIf:    desktopOnly,
+			Installed: executable("discord"),
+		}, vendorDeb{
+			URL:     "https://discord.com/api/download?platform=linux&format=deb",
+			Package: "discord",
+		}),
+	}
+}
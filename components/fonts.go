@@ -0,0 +1,194 @@
+package components
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/log"
+
+	"github.com/timmo001/bootstrap/steps"
+	u "github.com/timmo001/bootstrap/utils"
+)
+
+const nerdFontsURL = "https://github.com/ryanoasis/nerd-fonts/releases/latest/download/"
+
+// Where earlier versions cloned the whole repo, relative to the working
+// directory
+const (
+	legacyNerdFontsRepo = "https://github.com/ryanoasis/nerd-fonts"
+	legacyNerdFontsDir  = "nerd-fonts"
+)
+
+// nerdFont is a Nerd Fonts family, named as in the release archives.
+type nerdFont struct {
+	Family string
+	// Name is the family as fontconfig lists it
+	Name string
+	// Monospace is GNOME's monospace font when the family is the first selected
+	Monospace string
+}
+
+// The families installed when none are picked with --fonts
+var nerdFonts = []nerdFont{
+	{"FiraMono", "FiraMono Nerd Font", "FiraMono Nerd Font Medium 13"},
+	{"Hack", "Hack Nerd Font", "Hack Nerd Font 13"},
+	{"JetBrainsMono", "JetBrainsMono Nerd Font", "JetBrainsMono Nerd Font 13"},
+}
+
+// Families the fonts are renamed in, as their licenses reserve the
+// original name
+var renamedFonts = map[string]string{
+	"CascadiaCode":   "CaskaydiaCove",
+	"CascadiaMono":   "CaskaydiaMono",
+	"DejaVuSansMono": "DejaVuSansM",
+	"Hermit":         "Hurmit",
+	"LiberationMono": "LiterationMono",
+	"ShareTechMono":  "ShureTechMono",
+	"SourceCodePro":  "SauceCodePro",
+	"Terminus":       "Terminess",
+}
+
+func selectedFonts(ctx *steps.Context) []nerdFont {
+	if len(ctx.Fonts) == 0 {
+		return nerdFonts
+	}
+
+	var fonts []nerdFont
+	for _, family := range ctx.Fonts {
+		i := slices.IndexFunc(nerdFonts, func(f nerdFont) bool { return f.Family == family })
+		if i >= 0 {
+			fonts = append(fonts, nerdFonts[i])
+		} else {
+			name := family
+			if renamed, ok := renamedFonts[family]; ok {
+				name = renamed
+			}
+			fonts = append(fonts, nerdFont{family, name + " Nerd Font", name + " Nerd Font 13"})
+		}
+	}
+	return fonts
+}
+
+func fontsDir(ctx *steps.Context) string {
+	if ctx.SystemFonts {
+		return "/usr/local/share/fonts/NerdFonts"
+	}
+	return ctx.Home + "/.local/share/fonts/NerdFonts"
+}
+
+func fontsInstalled(ctx *steps.Context) bool {
+	for _, f := range selectedFonts(ctx) {
+		if !u.ExistsPath(filepath.Join(fontsDir(ctx), f.Family)) {
+			return false
+		}
+	}
+	return true
+}
+
+func planFonts(ctx *steps.Context) ([]string, error) {
+	var plan []string
+	for _, f := range selectedFonts(ctx) {
+		if ctx.Force || !u.ExistsPath(filepath.Join(fontsDir(ctx), f.Family)) {
+			plan = append(plan, "download "+f.Family+" into "+fontsDir(ctx))
+		}
+	}
+	monospace := "'" + selectedFonts(ctx)[0].Monospace + "'"
+	if current, _ := u.CmdOutput("gsettings", "get", "org.gnome.desktop.interface", "monospace-font-name"); current != monospace {
+		plan = append(plan, "set the GNOME monospace font to "+monospace)
+	}
+	return plan, nil
+}
+
+func installNerdFonts(ctx *steps.Context) error {
+	return installFonts(ctx, ctx.Force)
+}
+
+func updateNerdFonts(ctx *steps.Context) error {
+	return installFonts(ctx, true)
+}
+
+// installFonts installs the selected families from the latest release,
+// skipping those already installed unless forced.
+func installFonts(ctx *steps.Context, force bool) error {
+	// Earlier versions cloned the whole repo, which is several GB
+	if u.IsCheckout(legacyNerdFontsRepo, legacyNerdFontsDir) {
+		if err := u.DeleteDir(legacyNerdFontsDir); err != nil {
+			ctx.Warn(err)
+		} else {
+			ctx.Change("removed the old " + legacyNerdFontsDir + " clone")
+		}
+	}
+
+	fonts := selectedFonts(ctx)
+	installed := false
+	for _, f := range fonts {
+		if !force && u.ExistsPath(filepath.Join(fontsDir(ctx), f.Family)) {
+			log.Infof("%s is already installed", f.Family)
+			continue
+		}
+		if err := installFont(ctx, f.Family); err != nil {
+			return err
+		}
+		ctx.Change("installed " + f.Family + " into " + fontsDir(ctx))
+		installed = true
+	}
+
+	if installed {
+		if err := asRoot(ctx.SystemFonts, "fc-cache", "-f", fontsDir(ctx)); err != nil {
+			return err
+		}
+	}
+	if err := verifyFonts(fonts); err != nil {
+		return err
+	}
+	return gsettingsSet("nerd-fonts", "org.gnome.desktop.interface", "monospace-font-name", "'"+fonts[0].Monospace+"'")
+}
+
+// installFont replaces the family's directory with the release archive's
+// fonts, so an update drops fonts the release no longer has.
+func installFont(ctx *steps.Context, family string) error {
+	tmp, err := os.MkdirTemp("", "nerd-fonts-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	archive := filepath.Join(tmp, family+".tar.xz")
+	if err := u.DownloadFile(nerdFontsURL+family+".tar.xz", archive); err != nil {
+		return err
+	}
+
+	dir := filepath.Join(fontsDir(ctx), family)
+	if err := asRoot(ctx.SystemFonts, "rm", "-rf", dir); err != nil {
+		return err
+	}
+	if err := asRoot(ctx.SystemFonts, "mkdir", "-p", dir); err != nil {
+		return err
+	}
+	return asRoot(ctx.SystemFonts, "tar", "-xJf", archive, "-C", dir, "--no-same-owner")
+}
+
+// verifyFonts checks fontconfig can see every family.
+func verifyFonts(fonts []nerdFont) error {
+	families, err := u.CmdOutput("fc-list", ":", "family")
+	if err != nil {
+		return err
+	}
+	for _, f := range fonts {
+		if !strings.Contains(families, f.Name) {
+			return fmt.Errorf("%s is not in the font cache after installing it", f.Name)
+		}
+	}
+	return nil
+}
+
+// asRoot runs the command with sudo when root is true.
+func asRoot(root bool, name string, arg ...string) error {
+	if root {
+		return u.RunCmd("sudo", append([]string{name}, arg...)...)
+	}
+	return u.RunCmd(name, arg...)
+}
@@ -0,0 +1,33 @@
+package components
+
+import (
+	"testing"
+
+	"github.com/timmo001/bootstrap/steps"
+)
+
+func TestSelectedFonts(t *testing.T) {
+	tests := []struct {
+		family        string
+		wantName      string
+		wantMonospace string
+	}{
+		{family: "FiraMono", wantName: "FiraMono Nerd Font", wantMonospace: "FiraMono Nerd Font Medium 13"},
+		{family: "Hack", wantName: "Hack Nerd Font", wantMonospace: "Hack Nerd Font 13"},
+		{family: "Meslo", wantName: "Meslo Nerd Font", wantMonospace: "Meslo Nerd Font 13"},
+		// Renamed in the release, so fc-list never shows the archive's name
+		{family: "CascadiaCode", wantName: "CaskaydiaCove Nerd Font", wantMonospace: "CaskaydiaCove Nerd Font 13"},
+		{family: "SourceCodePro", wantName: "SauceCodePro Nerd Font", wantMonospace: "SauceCodePro Nerd Font 13"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.family, func(t *testing.T) {
+			fonts := selectedFonts(&steps.Context{Fonts: []string{tt.family}})
+			if len(fonts) != 1 {
+				t.Fatalf("fonts = %+v, want one", fonts)
+			}
+			if f := fonts[0]; f.Family != tt.family || f.Name != tt.wantName || f.Monospace != tt.wantMonospace {
+				t.Errorf("font = %+v, want %s, %q, %q", f, tt.family, tt.wantName, tt.wantMonospace)
+			}
+		})
+	}
+}
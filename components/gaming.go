@@ -0,0 +1,56 @@
+package components
+
+import (
+	"github.com/timmo001/bootstrap/steps"
+	u "github.com/timmo001/bootstrap/utils"
+)
+
+// Sunshine only publishes debs for Ubuntu LTS releases
+var sunshineRelease = releaseAsset{
+	Repo:           "LizardByte/Sunshine",
+	Version:        "latest",
+	Asset:          "sunshine-{distro}-{distro_version}-{arch}.deb",
+	DistroVersions: []string{"22.04", "24.04"},
+}
+
+func gamingSteps() []*steps.Step {
+	return []*steps.Step{
+		fromDeb(&steps.Step{
+			ID:        "steam",
+			Title:     "Steam",
+			Tags:      []string{"desktop", "gaming"},
+			Requires:  []string{"curl"},
+			SkipIf:    desktopOnly,
+			Installed: executable("steam"),
+		}, vendorDeb{
+			URL:     "https://cdn.fastly.steamstatic.com/client/installer/steam.deb",
+			Package: "steam-launcher",
+		}),
+		fromRelease(&steps.Step{
+			ID:        "sunshine",
+			Title:     "Sunshine",
+			Tags:      []string{"desktop", "gaming"},
+			Requires:  []string{"curl"},
+			SkipIf:    desktopOnly,
+			Installed: executable("sunshine"),
+			Version:   aptVersion("sunshine"),
+			Uninstall: aptPurge("sunshine"),
+		}, sunshineRelease, func(ctx *steps.Context, file string) error {
+			return installDeb(ctx, file, "sunshine")
+		}),
+		{
+			ID:        "moonlight",
+			Title:     "Moonlight",
+			Tags:      []string{"desktop", "gaming"},
+			Requires:  []string{"flatpak"},
+			SkipIf:    desktopOnly,
+			Installed: flatpakApp("com.moonlight_stream.Moonlight"),
+			Version:   flatpakVersion("com.moonlight_stream.Moonlight"),
+			Update:    flatpakUpdate("com.moonlight_stream.Moonlight"),
+			Uninstall: flatpakUninstall("com.moonlight_stream.Moonlight"),
+			Run: func(ctx *steps.Context) error {
+				return u.RunCmd("flatpak", "install", "flathub", "com.moonlight_stream.Moonlight", "-y")
+			},
+		},
+	}
+}
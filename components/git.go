@@ -0,0 +1,161 @@
+package components
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/log"
+
+	"github.com/timmo001/bootstrap/backups"
+	"github.com/timmo001/bootstrap/steps"
+	u "github.com/timmo001/bootstrap/utils"
+)
+
+// gitSetting is a single git config key and its value.
+type gitSetting struct {
+	Key   string
+	Value string
+}
+
+// gitFile is a git config file and the settings bootstrap manages in it.
+// Other settings in the file are left alone.
+type gitFile struct {
+	Path     string
+	Settings []gitSetting
+	// Added are values of keys that can be set more than once, such as
+	// url.<base>.insteadOf, added alongside any others the key has
+	Added []gitSetting
+}
+
+// gitChange is a setting that differs from what is in the file.
+type gitChange struct {
+	File string
+	gitSetting
+	Old string
+	// Add adds the value to the key instead of replacing it
+	Add bool
+}
+
+func (c gitChange) String() string {
+	if c.Add {
+		return fmt.Sprintf("%s: add %s = %s", c.File, c.Key, c.Value)
+	}
+	if c.Old == "" {
+		return fmt.Sprintf("%s: set %s = %s", c.File, c.Key, c.Value)
+	}
+	return fmt.Sprintf("%s: set %s = %s (was %s)", c.File, c.Key, c.Value, c.Old)
+}
+
+// gitFiles returns the global config, and a file per profile that the
+// global config includes for the profile's directory.
+func gitFiles(ctx *steps.Context) []gitFile {
+	global := gitFile{
+		Path: ctx.Home + "/.gitconfig",
+		Settings: []gitSetting{
+			{"user.email", ctx.Email},
+			{"user.name", ctx.Name},
+			{"core.editor", "nvim"},
+			{"core.excludesFile", "~/.config/git/ignore"},
+			{"pull.rebase", "true"},
+			{"rebase.autoStash", "true"},
+			{"push.default", "current"},
+			{"alias.co", "checkout"},
+			{"alias.br", "branch"},
+			{"alias.st", "status -sb"},
+			{"alias.lg", "log --oneline --graph --decorate"},
+		},
+	}
+	// Sorted so the plan is the same from run to run
+	var prefixes []string
+	for prefix := range ctx.GitMirrors {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+	for _, prefix := range prefixes {
+		// Several prefixes can share a mirror, each is another insteadOf
+		global.Added = append(global.Added, gitSetting{"url." + ctx.GitMirrors[prefix] + ".insteadOf", prefix})
+	}
+	files := []gitFile{global}
+
+	for _, p := range ctx.GitProfiles {
+		file := gitFile{
+			Path: ctx.Home + "/.gitconfig-" + p.Name,
+			Settings: []gitSetting{
+				{"user.email", p.Email},
+				{"user.name", p.User},
+			},
+		}
+		files[0].Settings = append(files[0].Settings, gitSetting{"includeIf.gitdir:" + p.Dir + ".path", file.Path})
+		files = append(files, file)
+	}
+	return files
+}
+
+func (f gitFile) changes() []gitChange {
+	var changes []gitChange
+	for _, s := range f.Settings {
+		// Unset keys make git exit non-zero with no output
+		current, _ := u.CmdOutput("git", "config", "--file", f.Path, "--get", s.Key)
+		if current != s.Value {
+			changes = append(changes, gitChange{File: f.Path, gitSetting: s, Old: current})
+		}
+	}
+	for _, s := range f.Added {
+		current, _ := u.CmdOutput("git", "config", "--file", f.Path, "--get-all", s.Key)
+		if !slices.Contains(u.SplitLines(current), s.Value) {
+			changes = append(changes, gitChange{File: f.Path, gitSetting: s, Add: true})
+		}
+	}
+	return changes
+}
+
+func (f gitFile) plan() []string {
+	var plan []string
+	for _, c := range f.changes() {
+		plan = append(plan, c.String())
+	}
+	return plan
+}
+
+// apply sets only the settings that differ, so reruns change nothing.
+func (f gitFile) apply(ctx *steps.Context) error {
+	changes := f.changes()
+	if len(changes) == 0 {
+		return nil
+	}
+	if err := backups.Save(f.Path); err != nil {
+		return err
+	}
+
+	for _, c := range changes {
+		args := []string{"config", "--file", f.Path, c.Key, c.Value}
+		if c.Add {
+			args = []string{"config", "--file", f.Path, "--add", c.Key, c.Value}
+		}
+		log.Infof("git config %s", strings.TrimPrefix(strings.TrimPrefix(c.String(), f.Path+": set "), f.Path+": "))
+		if err := u.RunCmd("git", args...); err != nil {
+			return err
+		}
+		ctx.Change(c.String())
+	}
+	return nil
+}
+
+func planGit(ctx *steps.Context) ([]string, error) {
+	var plan []string
+	for _, f := range gitFiles(ctx) {
+		plan = append(plan, f.plan()...)
+	}
+	return plan, nil
+}
+
+func configureGit(ctx *steps.Context) error {
+	for _, f := range gitFiles(ctx) {
+		if err := f.apply(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package components
+
+import "testing"
+
+func TestGitMirrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		mirrors map[string]string
+		// urls maps what is fetched to where git fetches it from
+		urls map[string]string
+	}{
+		{
+			name:    "one prefix",
+			mirrors: map[string]string{"https://github.com/": "https://mirror.example.com/github/"},
+			urls:    map[string]string{"https://github.com/a/b": "https://mirror.example.com/github/a/b"},
+		},
+		{
+			name: "two prefixes, one mirror",
+			mirrors: map[string]string{
+				"https://github.com/": "https://mirror.example.com/github/",
+				"git@github.com:":     "https://mirror.example.com/github/",
+			},
+			urls: map[string]string{
+				"https://github.com/a/b": "https://mirror.example.com/github/a/b",
+				"git@github.com:a/b":     "https://mirror.example.com/github/a/b",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := testHome(t)
+			ctx.GitMirrors = tt.mirrors
+			runTwice(t, ctx, component(t, "git"), nil)
+			for from, want := range tt.urls {
+				if got := git(t, ctx.Home, "ls-remote", "--get-url", from); got != want {
+					t.Errorf("%s is fetched from %s, want %s", from, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package components
+
+import (
+	"github.com/timmo001/bootstrap/steps"
+	u "github.com/timmo001/bootstrap/utils"
+)
+
+func hyprlandSteps() []*steps.Step {
+	return []*steps.Step{
+		{
+			ID:        "hyprland",
+			Title:     "Hyprland",
+			Tags:      []string{"desktop", "hyprland"},
+			Requires:  []string{"apt-update"},
+			SkipIf:    desktopOnly,
+			Installed: executable("Hyprland"),
+			Version:   commandVersion("Hyprland", "--version"),
+			Update:    aptUpgrade("hyprland"),
+			Uninstall: aptPurge("hyprland", "hyprland-backgrounds", "wofi", "wofi-pass", "waybar", "sway-notification-center"),
+			Run: func(ctx *steps.Context) error {
+				return u.RunCmd("sudo", "apt", "install", "hyprland", "hyprland-backgrounds", "wofi", "wofi-pass", "wl-clipboard", "pseudo", "libgtk-4-dev", "waybar", "fonts-font-awesome", "clang-tidy", "gobject-introspection", "libdbusmenu-gtk3-dev", "libevdev-dev", "libfmt-dev", "libgirepository1.0-dev", "libgtk-3-dev", "libgtkmm-3.0-dev", "libinput-dev", "libjsoncpp-dev", "libmpdclient-dev", "libnl-3-dev", "libnl-genl-3-dev", "libpulse-dev", "libsigc++-2.0-dev", "libspdlog-dev", "libwayland-dev", "scdoc", "upower", "libxkbregistry-dev", "sway-notification-center", "light", "-y")
+			},
+		},
+		fromRelease(&steps.Step{
+			ID:        "catppuccin-cursor",
+			Title:     "Catppuccin Cursor",
+			Tags:      []string{"desktop", "hyprland"},
+			Requires:  []string{"curl"},
+			SkipIf:    desktopOnly,
+			Installed: existsPath("/usr/share/icons/catppuccin-mocha-dark-cursors"),
+			Uninstall: all(removePaths("/usr/share/icons/catppuccin-mocha-dark-cursors"), resetSettings("catppuccin-cursor")),
+		}, releaseAsset{
+			Repo:    "catppuccin/cursors",
+			Version: "latest",
+			Asset:   "catppuccin-mocha-dark-cursors.zip",
+		}, func(ctx *steps.Context, file string) error {
+			if err := u.RunCmd("sudo", "mkdir", "-p", "/usr/share/icons"); err != nil {
+				return err
+			}
+			// Replace the previous release's files
+			if err := u.RunCmd("sudo", "unzip", "-o", file, "-d", "/usr/share/icons"); err != nil {
+				return err
+			}
+			if err := gsettingsSet("catppuccin-cursor", "org.gnome.desktop.interface", "cursor-theme", "'catppuccin-mocha-dark-cursors'"); err != nil {
+				return err
+			}
+			return gsettingsSet("catppuccin-cursor", "org.gnome.desktop.interface", "cursor-size", "24")
+		}),
+		fromSource(&steps.Step{
+			ID:        "grimblast",
+			Title:     "Grimblast",
+			Tags:      []string{"desktop", "hyprland"},
+			Requires:  []string{"apt-update", "git", "ssh"},
+			SkipIf:    desktopOnly,
+			Installed: executable("grimblast"),
+		}, sourceBuild{
+			Repo:    "git@github.com:hyprwm/contrib",
+			Dir:     "hyprwm-contrib",
+			Prefix:  "/usr/local",
+			Deps:    []string{"grim", "slurp"},
+			Install: [][]string{{"sudo", "make", "-C", "grimblast", "install", "PREFIX={prefix}", "DESTDIR={destdir}"}},
+			Staged:  true,
+		}, nil),
+		{
+			ID:        "swaybg",
+			Tags:      []string{"desktop", "hyprland"},
+			Requires:  []string{"apt-update"},
+			SkipIf:    desktopOnly,
+			Installed: executable("swaybg"),
+			Version:   aptVersion("swaybg"),
+			Update:    aptUpgrade("swaybg"),
+			Uninstall: aptPurge("swaybg"),
+			Run: func(ctx *steps.Context) error {
+				return u.RunCmd("sudo", "apt", "install", "swaybg", "-y")
+			},
+		},
+	}
+}
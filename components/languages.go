@@ -0,0 +1,164 @@
+package components
+
+import (
+	"errors"
+
+	"github.com/timmo001/bootstrap/steps"
+	u "github.com/timmo001/bootstrap/utils"
+)
+
+func languageSteps() []*steps.Step {
+	return []*steps.Step{
+		{
+			ID:        "go",
+			Title:     "Checking Go",
+			Tags:      []string{"base", "dev"},
+			Installed: existsPath("/usr/local/go/bin/go"),
+			Version:   commandVersion("/usr/local/go/bin/go", "version"),
+			Env:       &steps.Env{Path: []string{"/usr/local/go/bin", "~/go/bin"}},
+			Run: func(ctx *steps.Context) error {
+				// init.sh installs Go, as it's needed to run this
+				if !u.ExistsPath("/usr/local/go/bin/go") {
+					return errors.New("go is not in /usr/local/go, run ./init.sh to install it")
+				}
+				return nil
+			},
+		},
+		{
+			ID:        "ruby",
+			Tags:      []string{"dev"},
+			Requires:  []string{"apt-update"},
+			Installed: executable("ruby"),
+			Version:   commandVersion("ruby", "--version"),
+			Update:    aptUpgrade("ruby"),
+			Uninstall: aptPurge("ruby"),
+			Run: func(ctx *steps.Context) error {
+				return aptInstall(ctx, "ruby")
+			},
+		},
+		{
+			ID:        "nodejs",
+			Title:     "Node.js",
+			Tags:      []string{"dev"},
+			Requires:  []string{"curl"},
+			Installed: homePath(".local/share/fnm/fnm"),
+			Version:   commandVersion("fnm", "--version"),
+			Probes:    []steps.Probe{nodeOnPathProbe},
+			Env: &steps.Env{
+				// The default alias covers this run, fnm's own hook switches versions per directory
+				Path:  []string{"~/.local/share/fnm", "~/.local/share/fnm/aliases/default/bin"},
+				Shell: []string{`command -v fnm >/dev/null && eval "$(fnm env --use-on-cd)"`},
+			},
+			Update:    installNode,
+			Uninstall: removeHomePaths(".local/share/fnm"),
+			Run:       installNode,
+		},
+		{
+			ID:        "python",
+			Title:     "Python and dependencies",
+			Tags:      []string{"dev"},
+			Requires:  []string{"apt-update"},
+			Installed: executable("python3"),
+			Version:   commandVersion("python3", "--version"),
+			Update:    aptUpgrade("python3", "python3-pip", "python3-venv"),
+			Run: func(ctx *steps.Context) error {
+				if err := u.RunCmd("sudo", "apt", "install", "python3", "python3-dev", "-y"); err != nil {
+					return err
+				}
+				if err := u.RunCmd("sudo", "apt", "install", "python3-pip", "-y"); err != nil {
+					return err
+				}
+				if err := u.RunCmd("sudo", "apt", "install", "python3-venv", "-y"); err != nil {
+					return err
+				}
+				return u.RunCmd(
+					"sudo", "apt", "install", "autoconf", "libssl-dev", "libxml2-dev", "libxslt1-dev", "libjpeg-dev", "libffi-dev",
+					"libudev-dev", "zlib1g-dev", "pkg-config", "libavformat-dev", "libavcodec-dev", "libavdevice-dev", "libavutil-dev",
+					"libswscale-dev", "libswresample-dev", "libavfilter-dev", "ffmpeg", "libgammu-dev", "-y",
+				)
+			},
+		},
+		{
+			ID:        "rust",
+			Tags:      []string{"dev"},
+			Requires:  []string{"curl"},
+			Installed: homePath(".cargo/bin/rustc"),
+			Version:   commandVersion("rustc", "--version"),
+			Env:       &steps.Env{Path: []string{"~/.cargo/bin"}},
+			Update:    command("rustup", "update"),
+			Uninstall: command("rustup", "self", "uninstall", "-y"),
+			Run: func(ctx *steps.Context) error {
+				if !ctx.Force && u.IsExecutableInstalled("rustc") {
+					return nil
+				}
+				return runScript("https://sh.rustup.rs", "rustup-init.sh", "-y")
+			},
+		},
+		{
+			ID:        "zig",
+			Tags:      []string{"dev"},
+			Installed: executable("zig"),
+			Version:   commandVersion("zig", "version"),
+			Update:    snapRefresh("zig"),
+			Uninstall: snapRemove("zig"),
+			Run: func(ctx *steps.Context) error {
+				return u.RunCmd("sudo", "snap", "install", "zig", "--classic", "--beta")
+			},
+		},
+		{
+			ID:        "bun",
+			Title:     "Bun",
+			Tags:      []string{"dev"},
+			Requires:  []string{"curl"},
+			Installed: homePath(".bun/bin/bun"),
+			Env: &steps.Env{
+				Path: []string{"~/.bun/bin"},
+				Vars: map[string]string{"BUN_INSTALL": "~/.bun"},
+			},
+			Update: func(ctx *steps.Context) error {
+				return u.RunCmd("bun", "upgrade")
+			},
+			Uninstall: removeHomePaths(".bun"),
+			Run: func(ctx *steps.Context) error {
+				return runScript("https://bun.sh/install", "bun-install.sh")
+			},
+		},
+		{
+			ID:        "yarn",
+			Title:     "Enabling Yarn",
+			Tags:      []string{"dev"},
+			Requires:  []string{"nodejs"},
+			Installed: executable("yarn"),
+			Version:   commandVersion("yarn", "--version"),
+			Uninstall: command("corepack", "disable", "yarn"),
+			Run: func(ctx *steps.Context) error {
+				if err := u.RunCmd("corepack", "enable", "yarn"); err != nil {
+					ctx.Warn(err)
+				}
+				return nil
+			},
+		},
+		{
+			ID:        "pnpm",
+			Title:     "Enabling pnpm",
+			Tags:      []string{"dev"},
+			Requires:  []string{"nodejs"},
+			Installed: executable("pnpm"),
+			Version:   commandVersion("pnpm", "--version"),
+			Uninstall: command("corepack", "disable", "pnpm"),
+			Run: func(ctx *steps.Context) error {
+				if err := u.RunCmd("corepack", "enable", "pnpm"); err != nil {
+					ctx.Warn(err)
+				}
+				return nil
+			},
+		},
+	}
+}
+
+func installNode(ctx *steps.Context) error {
+	if err := runScript("https://fnm.vercel.app/install", "fnm-install.sh", "--skip-shell"); err != nil {
+		return err
+	}
+	return u.RunCmd("fnm", "install", "22")
+}
@@ -0,0 +1,93 @@
+package components
+
+import (
+	"os/user"
+	"strings"
+
+	"github.com/timmo001/bootstrap/steps"
+	u "github.com/timmo001/bootstrap/utils"
+)
+
+var loginShellProbe = steps.Probe{
+	Name: "login shell",
+	Check: func(ctx *steps.Context) steps.ProbeResult {
+		current, err := user.Current()
+		if err != nil {
+			return steps.Warn("", "could not look up the current user: %v", err)
+		}
+		passwd, err := u.CmdOutput("getent", "passwd", current.Username)
+		if err != nil {
+			return steps.Warn("", "could not look up the login shell: %v", err)
+		}
+		fields := strings.Split(passwd, ":")
+		shell := fields[len(fields)-1]
+		if !strings.Contains(shell, "zsh") {
+			return steps.Fail("chsh -s $(which zsh)", "login shell is %s", shell)
+		}
+		return steps.Pass("login shell is %s", shell)
+	},
+}
+
+var nodeOnPathProbe = steps.Probe{
+	Name: "node on PATH",
+	Check: func(ctx *steps.Context) steps.ProbeResult {
+		// Ask a new shell, this process has the env applied already
+		out, err := u.CmdOutput("zsh", "-ic", "node --version")
+		if err != nil {
+			return steps.Fail("run bootstrap --only shell-rc and open a new shell", "node is not on PATH in zsh")
+		}
+		lines := strings.Split(out, "\n")
+		return steps.Pass("node %s", lines[len(lines)-1])
+	},
+}
+
+var dockerGroupProbe = steps.Probe{
+	Name: "docker group",
+	Check: func(ctx *steps.Context) steps.ProbeResult {
+		groups, err := u.CmdOutput("id", "-nG")
+		if err != nil {
+			return steps.Warn("", "could not list groups: %v", err)
+		}
+		for _, group := range strings.Fields(groups) {
+			if group == "docker" {
+				return steps.Pass("user is in the docker group")
+			}
+		}
+		return steps.Warn("sudo usermod -aG docker $USER, then log out and back in", "user is not in the docker group")
+	},
+}
+
+var nvimRunsProbe = steps.Probe{
+	Name: "runs",
+	Check: func(ctx *steps.Context) steps.ProbeResult {
+		if _, err := u.CmdOutput("nvim", "--headless", "+qall"); err != nil {
+			return steps.Fail("rebuild with bootstrap --only neovim", "nvim failed to start: %v", err)
+		}
+		return steps.Pass("nvim starts")
+	},
+}
+
+var gitIdentityProbe = steps.Probe{
+	Name: "identity",
+	Check: func(ctx *steps.Context) steps.ProbeResult {
+		name, _ := u.CmdOutput("git", "config", "--global", "user.name")
+		email, _ := u.CmdOutput("git", "config", "--global", "user.email")
+		if name == "" || email == "" {
+			return steps.Fail(`git config --global user.name "<name>" && git config --global user.email "<email>"`, "git identity is not set")
+		}
+		return steps.Pass("%s <%s>", name, email)
+	},
+}
+
+var fontsCachedProbe = steps.Probe{
+	Name: "fonts cached",
+	Check: func(ctx *steps.Context) steps.ProbeResult {
+		if _, err := u.CmdOutput("fc-list"); err != nil {
+			return steps.Warn("sudo apt install fontconfig", "could not list fonts: %v", err)
+		}
+		if err := verifyFonts(selectedFonts(ctx)); err != nil {
+			return steps.Warn("fc-cache -f", "%v", err)
+		}
+		return steps.Pass("Nerd Fonts are cached")
+	},
+}
@@ -0,0 +1,156 @@
+package components
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/charmbracelet/log"
+
+	"github.com/timmo001/bootstrap/state"
+	"github.com/timmo001/bootstrap/steps"
+	u "github.com/timmo001/bootstrap/utils"
+)
+
+// releaseAsset describes a component installed from a GitHub release.
+type releaseAsset struct {
+	// Repo is "owner/name" on GitHub
+	Repo string
+	// Version is "latest" or a constraint such as "0.23.x" or ">=1.0, <2".
+	// It can be overridden with --pin <id>@<version>.
+	Version string
+	// Asset is a glob for the file to download, with {distro},
+	// {distro_version}, {codename}, {arch} and {version} filled in
+	Asset string
+	// DistroVersions are the distro versions the project publishes assets
+	// for. {distro_version} becomes the newest of them that isn't newer
+	// than the machine's, or the newest of all on an older machine.
+	DistroVersions []string
+}
+
+// fromRelease makes s download the asset of the selected release on install
+// and update, and hand it to install. Nothing is downloaded when the
+// release last installed is still the one selected.
+func fromRelease(s *steps.Step, r releaseAsset, install func(ctx *steps.Context, file string) error) *steps.Step {
+	s.Run = func(ctx *steps.Context) error {
+		return r.install(ctx, s, install)
+	}
+	s.Update = s.Run
+	s.Plan = func(ctx *steps.Context) ([]string, error) {
+		_, release, asset, err := r.resolve(s)
+		if err != nil {
+			return nil, err
+		}
+		return []string{"install " + asset.Name + " from " + r.Repo + " " + release.Tag}, nil
+	}
+	if s.Version == nil {
+		s.Version = releaseVersion(s.ID)
+	}
+	undo := s.Uninstall
+	s.Uninstall = func(ctx *steps.Context) error {
+		if undo != nil {
+			if err := undo(ctx); err != nil {
+				return err
+			}
+		}
+		st, err := state.Load()
+		if err != nil {
+			return err
+		}
+		delete(st.Releases, s.ID)
+		return st.Save()
+	}
+	return s
+}
+
+// resolve finds the release and asset the version or pin selects.
+func (r releaseAsset) resolve(s *steps.Step) (*state.State, *u.Release, *u.Asset, error) {
+	st, err := state.Load()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	version := r.Version
+	if pin, ok := st.Pins[s.ID]; ok {
+		version = pin
+	}
+
+	releases, err := u.Releases(r.Repo)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	release, err := u.SelectRelease(releases, version)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	asset, err := release.FindAsset(r.platform(u.DetectPlatform()).Expand(r.Asset, release.Tag))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return st, release, asset, nil
+}
+
+// platform maps the machine's distro version to one with published assets.
+func (r releaseAsset) platform(p u.Platform) u.Platform {
+	if len(r.DistroVersions) == 0 {
+		return p
+	}
+	best, newest := "", ""
+	for _, v := range r.DistroVersions {
+		if newest == "" || u.CompareVersions(v, newest) > 0 {
+			newest = v
+		}
+		if u.CompareVersions(v, p.DistroVersion) <= 0 && (best == "" || u.CompareVersions(v, best) > 0) {
+			best = v
+		}
+	}
+	if best == "" {
+		best = newest
+	}
+	p.DistroVersion = best
+	return p
+}
+
+func (r releaseAsset) install(ctx *steps.Context, s *steps.Step, install func(ctx *steps.Context, file string) error) error {
+	st, release, asset, err := r.resolve(s)
+	if err != nil {
+		return err
+	}
+
+	last := st.Releases[s.ID]
+	if last != nil && last.Tag == release.Tag && last.Asset == asset.Name && s.Installed(ctx) && !ctx.Force {
+		log.Infof("%s %s is already installed", s.ID, release.Tag)
+		return nil
+	}
+
+	log.Infof("Installing %s %s from %s", s.ID, release.Tag, asset.Name)
+	tmp, err := os.MkdirTemp("", s.ID+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	file := filepath.Join(tmp, asset.Name)
+	if err := asset.Download(file); err != nil {
+		return err
+	}
+	if err := install(ctx, file); err != nil {
+		return err
+	}
+
+	if st.Releases == nil {
+		st.Releases = map[string]*state.Release{}
+	}
+	st.Releases[s.ID] = &state.Release{Repo: r.Repo, Tag: release.Tag, Asset: asset.Name, Installed: time.Now()}
+	return st.Save()
+}
+
+// releaseVersion reports the release recorded for the step.
+func releaseVersion(id string) func(*steps.Context) string {
+	return func(ctx *steps.Context) string {
+		st, err := state.Load()
+		if err != nil || st.Releases[id] == nil {
+			return ""
+		}
+		return st.Releases[id].Tag
+	}
+}
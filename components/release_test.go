@@ -0,0 +1,40 @@
+package components
+
+import (
+	"testing"
+
+	u "github.com/timmo001/bootstrap/utils"
+)
+
+func TestSunshineAsset(t *testing.T) {
+	release := &u.Release{Tag: "v2025.122.141614", Assets: []u.Asset{
+		{Name: "sunshine-ubuntu-22.04-amd64.deb"},
+		{Name: "sunshine-ubuntu-24.04-amd64.deb"},
+		{Name: "sunshine-ubuntu-24.04-arm64.deb"},
+	}}
+	tests := []struct {
+		version string
+		arch    string
+		want    string
+	}{
+		{version: "22.04", arch: "amd64", want: "sunshine-ubuntu-22.04-amd64.deb"},
+		{version: "22.10", arch: "amd64", want: "sunshine-ubuntu-22.04-amd64.deb"},
+		{version: "24.04", arch: "amd64", want: "sunshine-ubuntu-24.04-amd64.deb"},
+		{version: "24.10", arch: "amd64", want: "sunshine-ubuntu-24.04-amd64.deb"},
+		{version: "25.04", arch: "arm64", want: "sunshine-ubuntu-24.04-arm64.deb"},
+		// Older than any published deb
+		{version: "20.04", arch: "amd64", want: "sunshine-ubuntu-24.04-amd64.deb"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version+"-"+tt.arch, func(t *testing.T) {
+			p := sunshineRelease.platform(u.Platform{Distro: "ubuntu", DistroVersion: tt.version, Codename: "oracular", Arch: tt.arch})
+			asset, err := release.FindAsset(p.Expand(sunshineRelease.Asset, release.Tag))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if asset.Name != tt.want {
+				t.Errorf("asset = %s, want %s", asset.Name, tt.want)
+			}
+		})
+	}
+}
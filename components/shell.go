@@ -0,0 +1,310 @@
+package components
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/log"
+
+	"github.com/timmo001/bootstrap/backups"
+	"github.com/timmo001/bootstrap/dotfiles"
+	"github.com/timmo001/bootstrap/state"
+	"github.com/timmo001/bootstrap/steps"
+	u "github.com/timmo001/bootstrap/utils"
+)
+
+func shellSteps() []*steps.Step {
+	return []*steps.Step{
+		{
+			ID:        "dotfiles",
+			Title:     "Setting up dotfiles",
+			Tags:      []string{"shell"},
+			Requires:  []string{"git", "ssh"},
+			Installed: homePath(".config/dotfiles"),
+			Version:   repoCommit(".config/dotfiles"),
+			Plan:      planDotfiles,
+			Update:    setupDotfiles,
+			Uninstall: unlinkDotfiles,
+			Run:       setupDotfiles,
+		},
+		{
+			ID:        "zsh-autosuggestions",
+			Tags:      []string{"shell"},
+			Requires:  []string{"apt-update"},
+			Installed: aptPackage("zsh-autosuggestions"),
+			Version:   aptVersion("zsh-autosuggestions"),
+			Update:    aptUpgrade("zsh-autosuggestions"),
+			Uninstall: aptPurge("zsh-autosuggestions"),
+			Run: func(ctx *steps.Context) error {
+				return aptInstall(ctx, "zsh-autosuggestions")
+			},
+		},
+		{
+			ID:        "zsh-syntax-highlighting",
+			Tags:      []string{"shell"},
+			Requires:  []string{"apt-update"},
+			Installed: aptPackage("zsh-syntax-highlighting"),
+			Version:   aptVersion("zsh-syntax-highlighting"),
+			Update:    aptUpgrade("zsh-syntax-highlighting"),
+			Uninstall: aptPurge("zsh-syntax-highlighting"),
+			Run: func(ctx *steps.Context) error {
+				return aptInstall(ctx, "zsh-syntax-highlighting")
+			},
+		},
+		{
+			ID:        "oh-my-zsh",
+			Tags:      []string{"shell"},
+			Requires:  []string{"zsh", "curl"},
+			Installed: homePath(".oh-my-zsh"),
+			Update: func(ctx *steps.Context) error {
+				omzPath := ctx.Home + "/.oh-my-zsh"
+				return u.RunCmdNoInput("env", "ZSH="+omzPath, "sh", omzPath+"/tools/upgrade.sh")
+			},
+			Uninstall: removeHomePaths(".oh-my-zsh"),
+			Run: func(ctx *steps.Context) error {
+				exists, err := u.ExistsDir(ctx.Home + "/.oh-my-zsh")
+				if err != nil {
+					return err
+				}
+				if !ctx.Force && exists {
+					return nil
+				}
+				if err := u.DeleteDir(ctx.Home + "/.oh-my-zsh"); err != nil {
+					return err
+				}
+				// The installer replaces ~/.zshrc
+				if err := backups.Save(ctx.Home + "/.zshrc"); err != nil {
+					return err
+				}
+				if err := u.DownloadFile("https://raw.github.com/ohmyzsh/ohmyzsh/master/tools/install.sh", "omz-install.sh"); err != nil {
+					return err
+				}
+				if err := u.RunCmdNoInput("sh", "omz-install.sh"); err != nil {
+					log.Errorf("error: %v", err)
+					if err := u.DeleteFile("omz-install.sh"); err != nil {
+						return err
+					}
+					return err
+				}
+				return u.DeleteFile("omz-install.sh")
+			},
+		},
+		{
+			ID:        "omz-plugins",
+			Title:     "Downloading oh-my-zsh plugins",
+			Tags:      []string{"shell"},
+			Requires:  []string{"git", "ssh", "oh-my-zsh"},
+			Installed: homePath(".oh-my-zsh/custom/plugins/zsh-autocomplete"),
+			Update:    cloneOmzPlugins,
+			Uninstall: removeHomePaths(".oh-my-zsh/custom/plugins/zsh-autosuggestions", ".oh-my-zsh/custom/plugins/zsh-syntax-highlighting", ".oh-my-zsh/custom/plugins/fast-syntax-highlighting", ".oh-my-zsh/custom/plugins/zsh-autocomplete"),
+			Run:       cloneOmzPlugins,
+		},
+		{
+			ID:        "starship",
+			Tags:      []string{"shell"},
+			Requires:  []string{"curl"},
+			Installed: executable("starship"),
+			Update: func(ctx *steps.Context) error {
+				return runScript("https://starship.rs/install.sh", "starship-install.sh", "--yes")
+			},
+			Uninstall: removePaths("/usr/local/bin/starship"),
+			Version:   commandVersion("starship", "--version"),
+			Run: func(ctx *steps.Context) error {
+				if !ctx.Force && u.IsExecutableInstalled("starship") {
+					return nil
+				}
+				return runScript("https://starship.rs/install.sh", "starship-install.sh", "--yes")
+			},
+		},
+		{
+			ID:        "shell-rc",
+			Title:     "Setting up shell rc files",
+			Tags:      []string{"base", "shell"},
+			Installed: rcBlocksWritten,
+			Update:    writeRcBlocks,
+			Uninstall: removeRcBlocks,
+			Run:       writeRcBlocks,
+		},
+	}
+}
+
+var rcFiles = []string{".bashrc", ".zshrc"}
+
+// rcBlock is the content of the block bootstrap manages in each rc file.
+// Everything else lives in the env file it sources.
+func rcBlock(ctx *steps.Context) string {
+	envFile := steps.EnvFile(ctx)
+	if rest, ok := strings.CutPrefix(envFile, ctx.Home+"/"); ok {
+		envFile = "$HOME/" + rest
+	}
+	return strings.Join([]string{
+		"# Managed by bootstrap, changes inside this block are overwritten",
+		fmt.Sprintf(`[ -f "%s" ] && . "%s"`, envFile, envFile),
+	}, "\n")
+}
+
+// rcTarget returns the file to write the rc block to. An rc file linked
+// from a git checkout, such as the dotfiles repo, would leave the checkout
+// dirty, so the block goes in <rc>.local instead and linked is the file in
+// the checkout.
+func rcTarget(ctx *steps.Context, rc string) (file, linked string) {
+	file = ctx.Home + "/" + rc
+	info, err := os.Lstat(file)
+	if err != nil || info.Mode()&os.ModeSymlink == 0 {
+		return file, ""
+	}
+	target, err := filepath.EvalSymlinks(file)
+	if err != nil {
+		return file, ""
+	}
+	if _, err := u.CmdOutput("git", "-C", filepath.Dir(target), "rev-parse", "--is-inside-work-tree"); err != nil {
+		return file, ""
+	}
+	return file + ".local", target
+}
+
+func rcBlocksWritten(ctx *steps.Context) bool {
+	for _, rc := range rcFiles {
+		file, _ := rcTarget(ctx, rc)
+		if _, ok, err := u.ReadBlock(file); err != nil || !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func writeRcBlocks(ctx *steps.Context) error {
+	for _, rc := range rcFiles {
+		file, linked := rcTarget(ctx, rc)
+		if linked != "" {
+			// Earlier versions wrote the block into the checkout
+			if err := u.RemoveBlock(linked); err != nil {
+				return err
+			}
+			if data, _ := os.ReadFile(linked); !strings.Contains(string(data), rc+".local") {
+				ctx.Warn(fmt.Errorf("~/%s is linked from %s, source ~/%s.local from it to load bootstrap's environment", rc, linked, rc))
+			}
+		} else {
+			// init.sh used to append this line itself, the block replaces it
+			if err := u.RemoveLines(file, "export PATH=$PATH:/usr/local/go/bin"); err != nil {
+				return err
+			}
+		}
+		if err := u.WriteBlock(file, rcBlock(ctx)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func removeRcBlocks(ctx *steps.Context) error {
+	for _, rc := range rcFiles {
+		file, _ := rcTarget(ctx, rc)
+		if err := u.RemoveBlock(file); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func dotfilesLinker(ctx *steps.Context) *dotfiles.Linker {
+	return &dotfiles.Linker{
+		Dir:      ctx.Home + "/.config/dotfiles",
+		Target:   ctx.Home,
+		Packages: ctx.Dotfiles,
+		Conflict: ctx.DotfilesConflict,
+	}
+}
+
+func planDotfiles(ctx *steps.Context) ([]string, error) {
+	linker := dotfilesLinker(ctx)
+	if !u.ExistsPath(linker.Dir) {
+		return []string{"clone git@github.com:timmo001/dotfiles into " + linker.Dir, "link the dotfiles packages"}, nil
+	}
+
+	actions, err := linker.Plan()
+	var changes []string
+	for _, a := range actions {
+		changes = append(changes, a.String())
+	}
+	var conflict *dotfiles.ConflictError
+	if errors.As(err, &conflict) {
+		for _, path := range conflict.Paths {
+			changes = append(changes, "conflict "+path)
+		}
+		for _, path := range conflict.Dirs {
+			changes = append(changes, "conflict "+path+" (a directory)")
+		}
+		return changes, nil
+	}
+	return changes, err
+}
+
+func setupDotfiles(ctx *steps.Context) error {
+	linker := dotfilesLinker(ctx)
+	st, err := state.Load()
+	if err != nil {
+		return err
+	}
+	// Pinned with --pin dotfiles@<ref>
+	update, err := u.UpdateOrCloneRepo("git@github.com:timmo001/dotfiles", linker.Dir, st.Pins["dotfiles"])
+	if err != nil {
+		return err
+	}
+	if update.Changed() {
+		ctx.Change(linker.Dir + ": " + update.String())
+	}
+
+	actions, err := linker.Plan()
+	if err != nil {
+		return err
+	}
+	return applyDotfiles(ctx, actions)
+}
+
+func unlinkDotfiles(ctx *steps.Context) error {
+	actions, err := dotfilesLinker(ctx).UnlinkPlan()
+	if err != nil {
+		return err
+	}
+	return applyDotfiles(ctx, actions)
+}
+
+func applyDotfiles(ctx *steps.Context, actions []dotfiles.Action) error {
+	if len(actions) == 0 {
+		log.Info("Dotfiles are already linked")
+		return nil
+	}
+	if err := dotfiles.Apply(actions); err != nil {
+		return err
+	}
+	for _, a := range actions {
+		ctx.Change(a.String())
+	}
+	return nil
+}
+
+var omzPlugins = []string{
+	"git@github.com:zsh-users/zsh-autosuggestions.git",
+	"git@github.com:zsh-users/zsh-syntax-highlighting.git",
+	"git@github.com:zdharma-continuum/fast-syntax-highlighting.git",
+	"git@github.com:marlonrichert/zsh-autocomplete.git",
+}
+
+func cloneOmzPlugins(ctx *steps.Context) error {
+	pluginsDir := ctx.Home + "/.oh-my-zsh/custom/plugins"
+	for _, repo := range omzPlugins {
+		name := strings.TrimSuffix(path.Base(repo), ".git")
+		update, err := u.UpdateOrCloneRepo(repo, pluginsDir+"/"+name, "")
+		if err != nil {
+			ctx.Warn(err)
+		} else if update.Changed() {
+			ctx.Change(name + ": " + update.String())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package components
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/timmo001/bootstrap/dotfiles"
+)
+
+func TestWriteRcBlocks(t *testing.T) {
+	tests := []struct {
+		name string
+		// linked links the rc files from a dotfiles checkout
+		linked bool
+		// sourced has the checkout's rc files source the .local file
+		sourced bool
+		// file is where the block should end up
+		file     string
+		warnings int
+	}{
+		{name: "plain files", file: ".zshrc"},
+		{name: "linked from dotfiles", linked: true, sourced: true, file: ".zshrc.local"},
+		{name: "linked without sourcing .local", linked: true, file: ".zshrc.local", warnings: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := testHome(t)
+			repo := ctx.Home + "/.config/dotfiles"
+			if tt.linked {
+				for _, rc := range rcFiles {
+					content := "# " + rc + "\n"
+					if tt.sourced {
+						content += "[ -f ~/" + rc + ".local ] && . ~/" + rc + ".local\n"
+					}
+					writeFile(t, repo+"/shell/"+rc, content)
+				}
+				git(t, repo, "init", "-q")
+				git(t, repo, "add", "-A")
+				git(t, repo, "commit", "-q", "-m", "dotfiles")
+				actions, err := dotfilesLinker(ctx).Plan()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := dotfiles.Apply(actions); err != nil {
+					t.Fatal(err)
+				}
+			} else {
+				for _, rc := range rcFiles {
+					writeFile(t, ctx.Home+"/"+rc, "# mine\nexport PATH=$PATH:/usr/local/go/bin\n")
+				}
+			}
+
+			// The second run is the one that used to fail on a dirty checkout
+			s := component(t, "shell-rc")
+			runTwice(t, ctx, s, func(run int) {
+				if len(ctx.Warnings) != tt.warnings {
+					t.Errorf("run %d: warnings = %q, want %d", run, ctx.Warnings, tt.warnings)
+				}
+				if tt.linked {
+					if status := git(t, repo, "status", "--porcelain"); status != "" {
+						t.Errorf("run %d: dotfiles checkout is dirty:\n%s", run, status)
+					}
+				}
+			})
+
+			data, err := os.ReadFile(ctx.Home + "/" + tt.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(string(data), rcBlock(ctx)) {
+				t.Errorf("%s has no rc block:\n%s", tt.file, data)
+			}
+			if strings.Contains(string(data), "/usr/local/go/bin") {
+				t.Errorf("%s still has the old PATH line:\n%s", tt.file, data)
+			}
+			uninstall(t, ctx, s)
+		})
+	}
+}
@@ -0,0 +1,215 @@
+package components
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/log"
+
+	"github.com/timmo001/bootstrap/steps"
+	u "github.com/timmo001/bootstrap/utils"
+)
+
+// The git settings the signing step owns, unset again on uninstall
+var signingKeys = []string{"gpg.format", "user.signingkey", "gpg.ssh.allowedSignersFile", "commit.gpgsign", "tag.gpgsign"}
+
+func signingOff(ctx *steps.Context) string {
+	if ctx.Signing == "" {
+		return "commit signing is not enabled, use --signing ssh or --signing gpg"
+	}
+	return ""
+}
+
+func allowedSigners(ctx *steps.Context) string {
+	return ctx.Home + "/.config/git/allowed_signers"
+}
+
+func gpgHome(ctx *steps.Context) string {
+	if dir := os.Getenv("GNUPGHOME"); dir != "" {
+		return dir
+	}
+	return ctx.Home + "/.gnupg"
+}
+
+// signingFile is the global config with the settings that sign commits
+// and tags with key, a public key file for ssh or a fingerprint for gpg.
+func signingFile(ctx *steps.Context, key string) gitFile {
+	f := gitFile{Path: ctx.Home + "/.gitconfig"}
+	if ctx.Signing == steps.SigningSSH {
+		f.Settings = []gitSetting{
+			{"gpg.format", "ssh"},
+			{"user.signingkey", key},
+			{"gpg.ssh.allowedSignersFile", allowedSigners(ctx)},
+		}
+	} else {
+		f.Settings = []gitSetting{
+			{"gpg.format", "openpgp"},
+			{"user.signingkey", key},
+		}
+	}
+	f.Settings = append(f.Settings, gitSetting{"commit.gpgsign", "true"}, gitSetting{"tag.gpgsign", "true"})
+	return f
+}
+
+func signingConfigured(ctx *steps.Context) bool {
+	global := ctx.Home + "/.gitconfig"
+	sign, _ := u.CmdOutput("git", "config", "--file", global, "--get", "commit.gpgsign")
+	key, _ := u.CmdOutput("git", "config", "--file", global, "--get", "user.signingkey")
+	return sign == "true" && key != ""
+}
+
+// gpgKey returns the fingerprint of the secret key for the email, or "".
+func gpgKey(ctx *steps.Context) string {
+	out, err := u.CmdOutput("gpg", "--homedir", gpgHome(ctx), "--list-secret-keys", "--with-colons", ctx.Email)
+	if err != nil {
+		return ""
+	}
+	primary := false
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Split(line, ":")
+		switch {
+		case fields[0] == "sec":
+			primary = true
+		case fields[0] == "fpr" && primary && len(fields) > 9:
+			return fields[9]
+		}
+	}
+	return ""
+}
+
+// signingKey generates a key if there isn't one, and returns the value for
+// user.signingkey and the public key to register.
+func signingKey(ctx *steps.Context) (string, string, error) {
+	if ctx.Signing == steps.SigningSSH {
+		public, err := ensureSSHKey(ctx)
+		return sshKey(ctx) + ".pub", public, err
+	}
+
+	fingerprint := gpgKey(ctx)
+	if fingerprint == "" {
+		if err := os.MkdirAll(gpgHome(ctx), 0700); err != nil {
+			return "", "", err
+		}
+		uid := fmt.Sprintf("%s <%s>", ctx.Name, ctx.Email)
+		if err := u.RunCmd("gpg", "--homedir", gpgHome(ctx), "--batch", "--passphrase", "", "--quick-generate-key", uid, "ed25519", "sign", "never"); err != nil {
+			return "", "", err
+		}
+		if fingerprint = gpgKey(ctx); fingerprint == "" {
+			return "", "", fmt.Errorf("no gpg key for %s after generating one", ctx.Email)
+		}
+		log.Infof("Generated gpg key %s without a passphrase, add one with: gpg --edit-key %s passwd", fingerprint, fingerprint)
+		ctx.Change("generated gpg key " + fingerprint)
+	}
+	public, err := u.CmdOutput("gpg", "--homedir", gpgHome(ctx), "--armor", "--export", fingerprint)
+	return fingerprint, public, err
+}
+
+// signersBlock lists the public key for every identity, so git can verify
+// ssh signatures made by this machine.
+func signersBlock(ctx *steps.Context, public string) string {
+	emails := []string{ctx.Email}
+	for _, p := range ctx.GitProfiles {
+		if !slices.Contains(emails, p.Email) {
+			emails = append(emails, p.Email)
+		}
+	}
+
+	var lines []string
+	for _, email := range emails {
+		if email != "" {
+			lines = append(lines, fmt.Sprintf(`%s namespaces="git" %s`, email, public))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func planSigning(ctx *steps.Context) ([]string, error) {
+	var plan []string
+	var key string
+	if ctx.Signing == steps.SigningSSH {
+		key = sshKey(ctx) + ".pub"
+		if !u.ExistsPath(sshKey(ctx)) {
+			plan = append(plan, "generate an ed25519 key at "+sshKey(ctx))
+		}
+		if public, err := os.ReadFile(key); err != nil {
+			plan = append(plan, "write "+allowedSigners(ctx))
+		} else {
+			current, _, err := u.ReadBlock(allowedSigners(ctx))
+			if err != nil {
+				return nil, err
+			}
+			if current != signersBlock(ctx, strings.TrimSpace(string(public))) {
+				plan = append(plan, "write "+allowedSigners(ctx))
+			}
+		}
+	} else {
+		if key = gpgKey(ctx); key == "" {
+			plan = append(plan, "generate a gpg key for "+ctx.Email)
+			key = "<new key>"
+		}
+	}
+	return append(plan, signingFile(ctx, key).plan()...), nil
+}
+
+func setupSigning(ctx *steps.Context) error {
+	key, public, err := signingKey(ctx)
+	if err != nil {
+		return err
+	}
+
+	if ctx.Signing == steps.SigningSSH {
+		if err := os.MkdirAll(ctx.Home+"/.config/git", 0755); err != nil {
+			return err
+		}
+		if err := u.WriteBlock(allowedSigners(ctx), signersBlock(ctx, public)); err != nil {
+			return err
+		}
+	}
+	if err := signingFile(ctx, key).apply(ctx); err != nil {
+		return err
+	}
+
+	log.Info("Add this key to GitHub as a signing key (Settings > SSH and GPG keys):")
+	fmt.Fprintln(u.Stdout, public)
+	return nil
+}
+
+// removeSigning stops signing commits. The keys are kept, they may be in use
+// elsewhere.
+func removeSigning(ctx *steps.Context) error {
+	global := ctx.Home + "/.gitconfig"
+	for _, key := range signingKeys {
+		if _, err := u.CmdOutput("git", "config", "--file", global, "--get", key); err != nil {
+			continue
+		}
+		if err := u.RunCmd("git", "config", "--file", global, "--unset", key); err != nil {
+			return err
+		}
+	}
+	return u.RemoveBlock(allowedSigners(ctx))
+}
+
+var signingKeyProbe = steps.Probe{
+	Name: "signing key",
+	Check: func(ctx *steps.Context) steps.ProbeResult {
+		global := ctx.Home + "/.gitconfig"
+		format, _ := u.CmdOutput("git", "config", "--file", global, "--get", "gpg.format")
+		key, _ := u.CmdOutput("git", "config", "--file", global, "--get", "user.signingkey")
+		if format == "" {
+			format = "openpgp"
+		}
+		switch {
+		case key == "":
+			return steps.Fail("run bootstrap --only git-signing --signing ssh", "user.signingkey is not set")
+		case format == "ssh" && !u.ExistsPath(key):
+			return steps.Fail("run bootstrap --only git-signing --signing ssh", "%s is missing", key)
+		case format != "ssh":
+			if _, err := u.CmdOutput("gpg", "--homedir", gpgHome(ctx), "--list-secret-keys", key); err != nil {
+				return steps.Fail("run bootstrap --only git-signing --signing gpg", "no gpg secret key %s", key)
+			}
+		}
+		return steps.Pass("signing with %s key %s", format, key)
+	},
+}
@@ -0,0 +1,108 @@
+package components
+
+import (
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/timmo001/bootstrap/steps"
+	u "github.com/timmo001/bootstrap/utils"
+)
+
+func gitConfig(t *testing.T, ctx *steps.Context, key string) string {
+	t.Helper()
+	out, _ := u.CmdOutput("git", "config", "--file", ctx.Home+"/.gitconfig", "--get", key)
+	return out
+}
+
+func TestSetupSigning(t *testing.T) {
+	tests := []struct {
+		name     string
+		signing  string
+		profiles []steps.GitProfile
+		// signers are the emails allowed_signers should list
+		signers []string
+	}{
+		{name: "ssh", signing: steps.SigningSSH, signers: []string{"test@example.com"}},
+		{
+			name:    "ssh with profiles",
+			signing: steps.SigningSSH,
+			profiles: []steps.GitProfile{
+				{Name: "work", Dir: "~/work", Email: "test@work.example.com"},
+				{Name: "same", Dir: "~/same", Email: "test@example.com"},
+			},
+			signers: []string{"test@example.com", "test@work.example.com"},
+		},
+		{name: "gpg", signing: steps.SigningGPG},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := exec.LookPath(map[string]string{steps.SigningSSH: "ssh-keygen", steps.SigningGPG: "gpg"}[tt.signing]); err != nil {
+				t.Skip(err)
+			}
+			ctx := testHome(t)
+			ctx.Signing = tt.signing
+			ctx.GitProfiles = tt.profiles
+			if tt.signing == steps.SigningGPG {
+				t.Setenv("GNUPGHOME", ctx.Home+"/.gnupg")
+				t.Cleanup(func() { exec.Command("gpgconf", "--homedir", ctx.Home+"/.gnupg", "--kill", "all").Run() })
+			}
+
+			// The key is generated on the first run and reused after
+			s := component(t, "git-signing")
+			var keys []string
+			runTwice(t, ctx, s, func(run int) {
+				generated := slices.ContainsFunc(ctx.Changes, func(c string) bool { return strings.HasPrefix(c, "generated") })
+				if generated != (run == 0) {
+					t.Errorf("run %d: changes = %q, want a key generated only on the first run", run, ctx.Changes)
+				}
+				keys = append(keys, gitConfig(t, ctx, "user.signingkey"))
+			})
+			if keys[0] == "" || keys[0] != keys[1] {
+				t.Errorf("user.signingkey = %q, want the same key on both runs", keys)
+			}
+
+			want := map[string]string{"commit.gpgsign": "true", "tag.gpgsign": "true"}
+			if tt.signing == steps.SigningSSH {
+				want["gpg.format"] = "ssh"
+				want["user.signingkey"] = sshKey(ctx) + ".pub"
+				want["gpg.ssh.allowedSignersFile"] = allowedSigners(ctx)
+			} else {
+				want["gpg.format"] = "openpgp"
+				want["user.signingkey"] = gpgKey(ctx)
+			}
+			for key, value := range want {
+				if got := gitConfig(t, ctx, key); got != value {
+					t.Errorf("%s = %q, want %q", key, got, value)
+				}
+			}
+
+			block, _, err := u.ReadBlock(allowedSigners(ctx))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var wantBlock []string
+			if len(tt.signers) > 0 {
+				public, err := os.ReadFile(sshKey(ctx) + ".pub")
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, email := range tt.signers {
+					wantBlock = append(wantBlock, email+` namespaces="git" `+strings.TrimSpace(string(public)))
+				}
+			}
+			if block != strings.Join(wantBlock, "\n") {
+				t.Errorf("allowed_signers block = %q, want %q", block, wantBlock)
+			}
+
+			uninstall(t, ctx, s)
+			for _, key := range signingKeys {
+				if got := gitConfig(t, ctx, key); got != "" {
+					t.Errorf("%s = %q after removing, want it unset", key, got)
+				}
+			}
+		})
+	}
+}
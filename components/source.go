@@ -0,0 +1,320 @@
+package components
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/log"
+
+	"github.com/timmo001/bootstrap/state"
+	"github.com/timmo001/bootstrap/steps"
+	u "github.com/timmo001/bootstrap/utils"
+)
+
+// sourceBuild describes a component built from a git repository.
+type sourceBuild struct {
+	Repo string
+	// Dir is where the repo is checked out, relative to the working directory
+	Dir string
+	// Ref is the tag, branch or commit to build, or a version pattern such
+	// as "v0.10.x". Empty builds the default branch. It can be overridden
+	// with --pin <id>@<ref>.
+	Ref    string
+	Prefix string
+	// Deps are apt packages needed to build and run the component
+	Deps []string
+	// Build and Install commands run in Dir, with {prefix} replaced by Prefix
+	Build   [][]string
+	Install [][]string
+	// Manifest is a file in Dir listing what Install put on disk, such as
+	// CMake's install_manifest.txt
+	Manifest string
+	// Staged runs Install with {destdir} set to a staging directory, whose
+	// contents are then recorded and copied into place
+	Staged bool
+}
+
+// fromSource makes s build b on install and update, and remove the files it
+// installed on uninstall. setup runs after the build when the component
+// wasn't installed before or with --force, and any Uninstall already set on
+// s runs after the files are removed.
+func fromSource(s *steps.Step, b sourceBuild, setup func(*steps.Context) error) *steps.Step {
+	s.Update = func(ctx *steps.Context) error {
+		return b.build(ctx, s)
+	}
+	undo := s.Uninstall
+	s.Uninstall = func(ctx *steps.Context) error {
+		if err := b.uninstall(ctx, s); err != nil {
+			return err
+		}
+		if undo == nil {
+			return nil
+		}
+		return undo(ctx)
+	}
+	s.Run = func(ctx *steps.Context) error {
+		installed := s.Installed(ctx)
+		if err := b.build(ctx, s); err != nil {
+			return err
+		}
+		if setup == nil || (installed && !ctx.Force) {
+			return nil
+		}
+		return setup(ctx)
+	}
+	return s
+}
+
+// build checks out the pinned ref and builds it, unless the ledger shows
+// that commit is already installed to the same prefix.
+func (b sourceBuild) build(ctx *steps.Context, s *steps.Step) error {
+	st, err := state.Load()
+	if err != nil {
+		return err
+	}
+
+	ref := b.Ref
+	if pin, ok := st.Pins[s.ID]; ok {
+		ref = pin
+	}
+	name, commit, err := u.ResolveRef(b.Repo, ref)
+	if err != nil {
+		return err
+	}
+
+	last := st.Builds[s.ID]
+	if last != nil && last.Commit == commit && last.Prefix == b.Prefix && s.Installed(ctx) && !ctx.Force {
+		log.Infof("%s is already built from %s (%s) into %s", s.ID, refName(ref), u.ShortCommit(commit), b.Prefix)
+		return nil
+	}
+
+	log.Infof("Building %s from %s (%s) into %s", s.ID, refName(ref), u.ShortCommit(commit), b.Prefix)
+	if name == "" {
+		name = commit
+	}
+	if err := u.CheckoutRef(b.Repo, b.Dir, name); err != nil {
+		return err
+	}
+	if len(b.Deps) > 0 {
+		if err := u.RunCmd("sudo", append(append([]string{"apt", "install"}, b.Deps...), "-y")...); err != nil {
+			return err
+		}
+	}
+	for _, cmd := range b.Build {
+		if err := u.RunCmdInDir(b.Dir, cmd[0], b.expand(cmd[1:], "")...); err != nil {
+			return err
+		}
+	}
+	files, dirs, err := b.install()
+	if err != nil {
+		return err
+	}
+
+	// Remove whatever the previous build installed that this one didn't
+	if last != nil {
+		if last.Prefix == b.Prefix {
+			// Directories an earlier build created are still this build's
+			created := map[string]bool{}
+			for _, dir := range last.Dirs {
+				created[dir] = true
+			}
+			dirs = append(dirs, newDirs(b.Prefix, files, func(dir string) bool { return !created[dir] })...)
+			slices.Sort(dirs)
+			dirs = slices.Compact(dirs)
+		}
+		if err := removeFiles(ctx, subtract(last.Files, files), subtract(last.Dirs, dirs)); err != nil {
+			return err
+		}
+	}
+
+	build := &state.Build{
+		Repo:   b.Repo,
+		Ref:    ref,
+		Commit: commit,
+		Prefix: b.Prefix,
+		Built:  time.Now(),
+		Files:  files,
+		Dirs:   dirs,
+	}
+	if s.Version != nil {
+		build.Version = s.Version(ctx)
+	}
+	if st.Builds == nil {
+		st.Builds = map[string]*state.Build{}
+	}
+	st.Builds[s.ID] = build
+	return st.Save()
+}
+
+// install runs the install commands and returns the files they installed,
+// and the directories under the prefix they created.
+func (b sourceBuild) install() ([]string, []string, error) {
+	destDir := ""
+	if b.Staged {
+		dir, err := os.MkdirTemp("", "bootstrap-stage-")
+		if err != nil {
+			return nil, nil, err
+		}
+		// The install runs as root, so the staged files are root's too
+		defer u.RunCmd("sudo", "rm", "-rf", dir)
+		destDir = dir
+	}
+	// The manifest only lists files, so note the directories there were
+	var existing map[string]bool
+	if !b.Staged && b.Manifest != "" {
+		existing = dirsUnder(b.Prefix)
+	}
+
+	for _, cmd := range b.Install {
+		if err := u.RunCmdInDir(b.Dir, cmd[0], b.expand(cmd[1:], destDir)...); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	switch {
+	case b.Staged:
+		files, err := stagedFiles(destDir)
+		if err != nil {
+			return nil, nil, err
+		}
+		dirs := newDirs(b.Prefix, files, u.ExistsPath)
+		// Merge into / without touching the modes of existing directories
+		return files, dirs, u.RunCmd("sudo", "sh", "-c", `tar -C "$1" -cf - . | tar -C / -xf - --no-overwrite-dir`, "sh", destDir)
+	case b.Manifest != "":
+		data, err := os.ReadFile(filepath.Join(b.Dir, b.Manifest))
+		if err != nil {
+			return nil, nil, err
+		}
+		files := u.SplitLines(string(data))
+		return files, newDirs(b.Prefix, files, func(dir string) bool { return existing[dir] }), nil
+	default:
+		return nil, nil, nil
+	}
+}
+
+// uninstall removes the files recorded for the last build.
+func (b sourceBuild) uninstall(ctx *steps.Context, s *steps.Step) error {
+	st, err := state.Load()
+	if err != nil {
+		return err
+	}
+
+	build := st.Builds[s.ID]
+	if build == nil || len(build.Files) == 0 {
+		return fmt.Errorf("no record of the files %s installed", s.ID)
+	}
+	if err := removeFiles(ctx, build.Files, build.Dirs); err != nil {
+		return err
+	}
+
+	delete(st.Builds, s.ID)
+	return st.Save()
+}
+
+func (b sourceBuild) expand(args []string, destDir string) []string {
+	expanded := make([]string, len(args))
+	for i, arg := range args {
+		arg = strings.ReplaceAll(arg, "{prefix}", b.Prefix)
+		expanded[i] = strings.ReplaceAll(arg, "{destdir}", destDir)
+	}
+	return expanded
+}
+
+// stagedFiles lists everything under destDir as paths relative to /.
+func stagedFiles(destDir string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(destDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		files = append(files, strings.TrimPrefix(path, destDir))
+		return nil
+	})
+	return files, err
+}
+
+// dirsUnder lists the directories under prefix.
+func dirsUnder(prefix string) map[string]bool {
+	dirs := map[string]bool{}
+	filepath.WalkDir(prefix, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && d.IsDir() {
+			dirs[path] = true
+		}
+		// Carry on past what can't be read
+		return nil
+	})
+	return dirs
+}
+
+// newDirs returns the directories under prefix holding files that didn't
+// exist, deepest first.
+func newDirs(prefix string, files []string, existed func(dir string) bool) []string {
+	seen := map[string]bool{}
+	var dirs []string
+	for _, file := range files {
+		for dir := filepath.Dir(file); strings.HasPrefix(dir, prefix+"/") && !seen[dir]; dir = filepath.Dir(dir) {
+			seen[dir] = true
+			if !existed(dir) {
+				dirs = append(dirs, dir)
+			}
+		}
+	}
+	return dirs
+}
+
+// removeFiles deletes files, then those of dirs they leave empty. Only
+// directories the install created are passed in, so nothing else under the
+// prefix is touched.
+func removeFiles(ctx *steps.Context, files, dirs []string) error {
+	// Keep the command lines a sensible length
+	for i := 0; i < len(files); i += 200 {
+		batch := files[i:min(i+200, len(files))]
+		if err := u.RunCmd("sudo", append([]string{"rm", "-f"}, batch...)...); err != nil {
+			return err
+		}
+	}
+
+	var remove []string
+	for _, dir := range dirs {
+		if u.ExistsPath(dir) {
+			remove = append(remove, dir)
+		}
+	}
+	// Deepest first, so parents are empty by the time they're reached
+	sort.Slice(remove, func(i, j int) bool { return len(remove[i]) > len(remove[j]) })
+	for i := 0; i < len(remove); i += 200 {
+		batch := remove[i:min(i+200, len(remove))]
+		if err := u.RunCmd("sudo", append([]string{"rmdir", "--ignore-fail-on-non-empty"}, batch...)...); err != nil {
+			ctx.Warn(fmt.Errorf("removing directories: %w", err))
+		}
+	}
+	return nil
+}
+
+// subtract returns the entries of a that aren't in b.
+func subtract(a, b []string) []string {
+	keep := map[string]bool{}
+	for _, s := range b {
+		keep[s] = true
+	}
+	var diff []string
+	for _, s := range a {
+		if !keep[s] {
+			diff = append(diff, s)
+		}
+	}
+	return diff
+}
+
+func refName(ref string) string {
+	if ref == "" {
+		return "the default branch"
+	}
+	return ref
+}
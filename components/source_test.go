@@ -0,0 +1,94 @@
+package components
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestRemoveFiles(t *testing.T) {
+	tests := []struct {
+		name string
+		// existing are directories under the prefix before the install
+		existing []string
+		files    []string
+		// wantDirs are the directories the install created
+		wantDirs []string
+		// wantLeft are the directories left after removing the files
+		wantLeft []string
+	}{
+		{
+			name:     "new directories",
+			files:    []string{"share/app/a", "share/app/icons/b"},
+			wantDirs: []string{"share", "share/app", "share/app/icons"},
+		},
+		{
+			name:     "empty system directory",
+			existing: []string{"share", "share/empty"},
+			files:    []string{"share/app/a", "share/empty/b"},
+			wantDirs: []string{"share/app"},
+			wantLeft: []string{"share", "share/empty"},
+		},
+		{
+			name:     "directory with other files",
+			existing: []string{"lib"},
+			files:    []string{"lib/app/a", "lib/other"},
+			wantDirs: []string{"lib/app"},
+			wantLeft: []string{"lib"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeSudo(t)
+			ctx := testHome(t)
+			prefix := t.TempDir()
+			for _, dir := range tt.existing {
+				if err := os.MkdirAll(filepath.Join(prefix, dir), 0755); err != nil {
+					t.Fatal(err)
+				}
+			}
+			// Everything listed is the install's, except lib/other
+			var files []string
+			for _, file := range tt.files {
+				files = append(files, filepath.Join(prefix, file))
+			}
+			dirs := newDirs(prefix, files, func(dir string) bool {
+				_, err := os.Stat(dir)
+				return err == nil
+			})
+			for _, file := range files {
+				writeFile(t, file, "x")
+			}
+			files = slices.DeleteFunc(files, func(f string) bool { return f == filepath.Join(prefix, "lib/other") })
+
+			var got []string
+			for _, dir := range dirs {
+				rel, _ := filepath.Rel(prefix, dir)
+				got = append(got, rel)
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, tt.wantDirs) {
+				t.Errorf("new dirs = %q, want %q", got, tt.wantDirs)
+			}
+
+			if err := removeFiles(ctx, files, dirs); err != nil {
+				t.Fatal(err)
+			}
+			if len(ctx.Warnings) > 0 {
+				t.Errorf("warnings = %q", ctx.Warnings)
+			}
+			var left []string
+			filepath.WalkDir(prefix, func(path string, d os.DirEntry, err error) error {
+				if err == nil && d.IsDir() && path != prefix {
+					rel, _ := filepath.Rel(prefix, path)
+					left = append(left, rel)
+				}
+				return nil
+			})
+			if !slices.Equal(left, tt.wantLeft) {
+				t.Errorf("left = %q, want %q", left, tt.wantLeft)
+			}
+		})
+	}
+}
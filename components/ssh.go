@@ -0,0 +1,173 @@
+package components
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/charmbracelet/log"
+
+	"github.com/timmo001/bootstrap/steps"
+	u "github.com/timmo001/bootstrap/utils"
+)
+
+// GitHub's published host keys, see
+// https://docs.github.com/en/authentication/keeping-your-account-and-data-secure/githubs-ssh-key-fingerprints
+var githubHostKeys = []string{
+	"github.com ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIOMqqnkVzrm0SdG6UOoqKLsabgH5C9okWi0dh2l9GKJl",
+	"github.com ecdsa-sha2-nistp256 AAAAE2VjZHNhLXNoYTItbmlzdHAyNTYAAAAIbmlzdHAyNTYAAABBBEmKSENjQEezOmxkZMy7opKgwFB9nkt5YRrYMjNuG5N87uRgg6CLrbo5wAdT/y6v0mKV0U2w0WZ2YB/++Tpockg=",
+	"github.com ssh-rsa AAAAB3NzaC1yc2EAAAADAQABAAABgQCj7ndNxQowgcQnjshcLrqPEiiphnt+VTTvDP6mHBL9j1aNUkY4Ue1gvwnGLVlOhGeYrnZaMgRK6+PKCUXaDbC7qtbW8gIkhL7aGCsOr/C56SJMy/BCZfxd1nWzAOxSDPgVsmerOBYfNqltV9/hWCqBywINIR+5dIg6JTJ72pcEpEjcYgXkE2YEFXV1JHnsKgbLWNlhScqb2UmyRkQyytRLtL+38TGxkxCflmO+5Z8CSSNY7GidjMIZ7Q4zMjA2n1nGrlTDkzwDCsw+wqFPGQA179cnfGWOWRVruj16z6XyvxvjJwbz0wQZ75XK5tKSb7FNyeIEs4TT4jk+S4dhPeAUC5y+bDYirYgM4GC7uEnztnZyaVWQ7B381AK4Qdrwt51ZqExKbQpTUNn+EjqoTwvqNj4kqx5QUCI0ThS/YkOxJCXmPUWZbhjpCg56i+2aB6CmK2JGhn57K5mj0MNdBXA4/WnwH6XoPWJzK5Nyu2zB3nAZp+S5hpQs+p1vN1/wsjk=",
+}
+
+func sshKey(ctx *steps.Context) string {
+	return ctx.Home + "/.ssh/id_ed25519"
+}
+
+func knownHosts(ctx *steps.Context) string {
+	return ctx.Home + "/.ssh/known_hosts"
+}
+
+func hostKeysTrusted(ctx *steps.Context) bool {
+	block, _, err := u.ReadBlock(knownHosts(ctx))
+	return err == nil && block == strings.Join(githubHostKeys, "\n")
+}
+
+func sshReady(ctx *steps.Context) bool {
+	return u.ExistsPath(sshKey(ctx)) && hostKeysTrusted(ctx)
+}
+
+// ensureSSHKey generates an ed25519 key if there isn't one, and returns the
+// public key.
+func ensureSSHKey(ctx *steps.Context) (string, error) {
+	key := sshKey(ctx)
+	if !u.ExistsPath(key) {
+		if err := os.MkdirAll(ctx.Home+"/.ssh", 0700); err != nil {
+			return "", err
+		}
+		if err := u.RunCmd("ssh-keygen", "-q", "-t", "ed25519", "-C", ctx.Email, "-N", "", "-f", key); err != nil {
+			return "", err
+		}
+		log.Infof("Generated %s without a passphrase, add one with: ssh-keygen -p -f %s", key, key)
+		ctx.Change("generated " + key)
+	} else if !u.ExistsPath(key + ".pub") {
+		// Recreate the public half from the private key
+		public, err := u.CmdOutput("ssh-keygen", "-y", "-f", key)
+		if err != nil {
+			return "", err
+		}
+		if err := os.WriteFile(key+".pub", []byte(public+"\n"), 0644); err != nil {
+			return "", err
+		}
+	}
+
+	public, err := os.ReadFile(key + ".pub")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(public)), nil
+}
+
+func planSSH(ctx *steps.Context) ([]string, error) {
+	var plan []string
+	if !u.ExistsPath(sshKey(ctx)) {
+		plan = append(plan, "generate an ed25519 key at "+sshKey(ctx))
+	}
+	if !hostKeysTrusted(ctx) {
+		plan = append(plan, "add GitHub's host keys to "+knownHosts(ctx))
+	}
+	if ctx.GitHubLogin {
+		plan = append(plan, "log in to GitHub with gh and add the key to the account")
+	}
+	return plan, nil
+}
+
+// setupSSH gets the machine ready for git@github.com clones.
+func setupSSH(ctx *steps.Context) error {
+	public, err := ensureSSHKey(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Pinned keys mean the first clone doesn't stop to ask about the host
+	if !hostKeysTrusted(ctx) {
+		if err := u.WriteBlock(knownHosts(ctx), strings.Join(githubHostKeys, "\n")); err != nil {
+			return err
+		}
+		ctx.Change("added GitHub's host keys to " + knownHosts(ctx))
+	}
+
+	if ctx.GitHubLogin {
+		return githubLogin(ctx, public)
+	}
+	log.Info("Add this key to GitHub (Settings > SSH and GPG keys), or rerun with --github-login:")
+	fmt.Fprintln(u.Stdout, public)
+	return nil
+}
+
+// githubLoginPrompts reports whether githubLogin will open a login or ask
+// for the scope to manage keys, both of which wait on the user.
+func githubLoginPrompts(ctx *steps.Context) bool {
+	if !ctx.GitHubLogin {
+		return false
+	}
+	_, err := u.CmdOutput("gh", "ssh-key", "list")
+	return err != nil
+}
+
+// githubLogin logs gh in if it isn't already, and adds the public key to
+// the account unless it is there.
+func githubLogin(ctx *steps.Context, public string) error {
+	if !u.IsExecutableInstalled("gh") {
+		return errors.New("gh is not installed, run bootstrap --only gh,ssh --github-login")
+	}
+
+	if _, err := u.CmdOutput("gh", "auth", "status", "--hostname", "github.com"); err != nil {
+		if err := u.RunCmd("gh", "auth", "login", "--hostname", "github.com", "--git-protocol", "ssh", "--skip-ssh-key", "--web", "--scopes", "admin:public_key"); err != nil {
+			return err
+		}
+	}
+
+	keys, err := u.CmdOutput("gh", "ssh-key", "list")
+	if err != nil {
+		// Logins from before this step lack the scope to manage keys
+		if err := u.RunCmd("gh", "auth", "refresh", "--hostname", "github.com", "--scopes", "admin:public_key"); err != nil {
+			return err
+		}
+		if keys, err = u.CmdOutput("gh", "ssh-key", "list"); err != nil {
+			return err
+		}
+	}
+
+	// The list has the key without its comment
+	fields := strings.Fields(public)
+	if len(fields) > 1 && strings.Contains(keys, fields[1]) {
+		log.Info("The ssh key is already on GitHub")
+		return nil
+	}
+	title, _ := os.Hostname()
+	if err := u.RunCmd("gh", "ssh-key", "add", sshKey(ctx)+".pub", "--title", title); err != nil {
+		return err
+	}
+	ctx.Change("added " + sshKey(ctx) + ".pub to GitHub")
+	return nil
+}
+
+// removeHostKeys takes the pinned keys out of known_hosts. The ssh key is
+// kept, it may be in use elsewhere.
+func removeHostKeys(ctx *steps.Context) error {
+	return u.RemoveBlock(knownHosts(ctx))
+}
+
+var githubSSHProbe = steps.Probe{
+	Name: "github ssh",
+	Check: func(ctx *steps.Context) steps.ProbeResult {
+		// GitHub exits 1 even when the key is accepted, so go by the greeting
+		out, _ := exec.Command("ssh", "-T", "-o", "BatchMode=yes", "-o", "ConnectTimeout=10", "git@github.com").CombinedOutput()
+		if strings.Contains(string(out), "successfully authenticated") {
+			return steps.Pass("%s", strings.TrimSpace(string(out)))
+		}
+		return steps.Fail("run bootstrap --only ssh --github-login", "github.com did not accept the ssh key")
+	},
+}
@@ -0,0 +1,210 @@
+package components
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/timmo001/bootstrap/steps"
+	u "github.com/timmo001/bootstrap/utils"
+)
+
+func systemSteps() []*steps.Step {
+	return []*steps.Step{
+		{
+			ID:     "apt-update",
+			Title:  "Update apt",
+			Tags:   []string{"base"},
+			Update: command("sudo", "apt", "update"),
+			Run: func(ctx *steps.Context) error {
+				return u.RunCmd("sudo", "apt", "update")
+			},
+		},
+		{
+			ID:       "apt-upgrade",
+			Title:    "Upgrade apt packages",
+			Tags:     []string{"base"},
+			Requires: []string{"apt-update"},
+			Update:   command("sudo", "apt", "full-upgrade", "-y"),
+			Run: func(ctx *steps.Context) error {
+				return u.RunCmd("sudo", "apt", "full-upgrade", "-y")
+			},
+		},
+		{
+			ID:    "apt-cleanup",
+			Title: "Cleanup apt packages",
+			Tags:  []string{"base"},
+			Run: func(ctx *steps.Context) error {
+				return u.RunCmd("sudo", "apt", "autoremove", "-y")
+			},
+		},
+		{
+			ID:        "editorconfig",
+			Title:     "Copying .editorconfig",
+			Tags:      []string{"base"},
+			Installed: homePath(".editorconfig"),
+			Uninstall: removeHomePaths(".editorconfig"),
+			Run: func(ctx *steps.Context) error {
+				return u.CopyFile(".editorconfig", ctx.Home+"/.editorconfig")
+			},
+		},
+		{
+			ID:        "zsh",
+			Title:     "Checking shell",
+			Tags:      []string{"base", "shell"},
+			Installed: executable("zsh"),
+			Version:   commandVersion("zsh", "--version"),
+			Probes:    []steps.Probe{loginShellProbe},
+			Run: func(ctx *steps.Context) error {
+				// Exit if the shell is not zsh
+				if !strings.Contains(ctx.Shell, "zsh") {
+					return errors.New("please restart your shell and run the script again in zsh to continue")
+				}
+				return nil
+			},
+		},
+		{
+			ID:        "wget",
+			Tags:      []string{"base"},
+			Requires:  []string{"apt-update"},
+			Installed: executable("wget"),
+			Version:   commandVersion("wget", "--version"),
+			Update:    aptUpgrade("wget"),
+			Uninstall: aptPurge("wget"),
+			Run: func(ctx *steps.Context) error {
+				return aptInstall(ctx, "wget")
+			},
+		},
+		{
+			ID:        "curl",
+			Tags:      []string{"base"},
+			Requires:  []string{"apt-update"},
+			Installed: executable("curl"),
+			Version:   commandVersion("curl", "--version"),
+			Update:    aptUpgrade("curl"),
+			Uninstall: aptPurge("curl"),
+			Run: func(ctx *steps.Context) error {
+				return aptInstall(ctx, "curl")
+			},
+		},
+		{
+			ID:        "flatpak",
+			Title:     "Setting up flatpak and flathub",
+			Tags:      []string{"base"},
+			Requires:  []string{"apt-update"},
+			Installed: executable("flatpak"),
+			Version:   commandVersion("flatpak", "--version"),
+			Update:    command("flatpak", "update", "-y"),
+			Uninstall: aptPurge("flatpak", "gnome-software-plugin-flatpak"),
+			Run: func(ctx *steps.Context) error {
+				if err := u.RunCmd("sudo", "apt", "install", "flatpak", "-y"); err != nil {
+					return err
+				}
+				if err := u.RunCmd("flatpak", "remote-add", "--if-not-exists", "flathub", "https://flathub.org/repo/flathub.flatpakrepo"); err != nil {
+					return err
+				}
+				return u.RunCmd("sudo", "apt", "install", "gnome-software-plugin-flatpak", "-y")
+			},
+		},
+		{
+			ID:        "pipewire",
+			Title:     "pipewire and wireplumber",
+			Tags:      []string{"base"},
+			Requires:  []string{"apt-update"},
+			Installed: aptPackage("pipewire"),
+			Version:   aptVersion("pipewire"),
+			Update:    aptUpgrade("pipewire", "wireplumber"),
+			Run: func(ctx *steps.Context) error {
+				return u.RunCmd("sudo", "apt", "install", "pipewire", "pipewire-audio-client-libraries", "wireplumber", "-y")
+			},
+		},
+		{
+			ID:        "gh",
+			Title:     "GitHub CLI (gh)",
+			Tags:      []string{"dev"},
+			Requires:  []string{"apt-update", "curl"},
+			Installed: executable("gh"),
+			Version:   commandVersion("gh", "--version"),
+			Update:    aptUpgrade("gh"),
+			Uninstall: all(aptPurge("gh"), removePaths("/etc/apt/sources.list.d/github-cli.list", "/etc/apt/keyrings/githubcli-archive-keyring.gpg"), command("sudo", "apt", "update")),
+			Run: func(ctx *steps.Context) error {
+				if !ctx.Force && u.IsExecutableInstalled("gh") {
+					return nil
+				}
+				tmp, err := os.MkdirTemp("", "gh-*")
+				if err != nil {
+					return err
+				}
+				defer os.RemoveAll(tmp)
+
+				keyring := "/etc/apt/keyrings/githubcli-archive-keyring.gpg"
+				if err := u.DownloadFile("https://cli.github.com/packages/githubcli-archive-keyring.gpg", filepath.Join(tmp, "keyring.gpg")); err != nil {
+					return err
+				}
+				if err := u.RunCmd("sudo", "install", "-D", "-m", "644", filepath.Join(tmp, "keyring.gpg"), keyring); err != nil {
+					return err
+				}
+
+				arch, err := u.CmdOutput("dpkg", "--print-architecture")
+				if err != nil {
+					return err
+				}
+				source := fmt.Sprintf("deb [arch=%s signed-by=%s] https://cli.github.com/packages stable main\n", arch, keyring)
+				if err := os.WriteFile(filepath.Join(tmp, "github-cli.list"), []byte(source), 0644); err != nil {
+					return err
+				}
+				if err := u.RunCmd("sudo", "install", "-D", "-m", "644", filepath.Join(tmp, "github-cli.list"), "/etc/apt/sources.list.d/github-cli.list"); err != nil {
+					return err
+				}
+				if err := u.RunCmd("sudo", "apt", "update"); err != nil {
+					return err
+				}
+				return u.RunCmd("sudo", "apt", "install", "gh", "-y")
+			},
+		},
+		{
+			ID:          "ssh",
+			Title:       "SSH key and GitHub host keys",
+			Tags:        []string{"base"},
+			Installed:   sshReady,
+			Probes:      []steps.Probe{githubSSHProbe},
+			Interactive: githubLoginPrompts,
+			Uninstall:   removeHostKeys,
+			Plan:        planSSH,
+			Update:      setupSSH,
+			Run:         setupSSH,
+		},
+		{
+			ID:        "git",
+			Tags:      []string{"base", "dev"},
+			Requires:  []string{"apt-update"},
+			Installed: executable("git"),
+			Version:   commandVersion("git", "--version"),
+			Probes:    []steps.Probe{gitIdentityProbe},
+			Update:    aptUpgrade("git"),
+			Uninstall: aptPurge("git"),
+			Plan:      planGit,
+			Run: func(ctx *steps.Context) error {
+				if err := aptInstall(ctx, "git"); err != nil {
+					return err
+				}
+				return configureGit(ctx)
+			},
+		},
+		{
+			ID:        "git-signing",
+			Title:     "Commit signing",
+			Tags:      []string{"dev"},
+			Requires:  []string{"git"},
+			SkipIf:    signingOff,
+			Installed: signingConfigured,
+			Probes:    []steps.Probe{signingKeyProbe},
+			Uninstall: removeSigning,
+			Plan:      planSigning,
+			Update:    setupSigning,
+			Run:       setupSigning,
+		},
+	}
+}
@@ -0,0 +1,211 @@
+package components
+
+import (
+	"github.com/timmo001/bootstrap/steps"
+	u "github.com/timmo001/bootstrap/utils"
+)
+
+func toolSteps() []*steps.Step {
+	return []*steps.Step{
+		{
+			ID:        "docker",
+			Title:     "Docker",
+			Tags:      []string{"dev"},
+			Requires:  []string{"curl"},
+			SkipIf:    notWSL,
+			Installed: executable("docker"),
+			Version:   commandVersion("docker", "--version"),
+			Probes:    []steps.Probe{dockerGroupProbe},
+			Update:    aptUpgrade("docker-ce", "docker-ce-cli", "containerd.io"),
+			Uninstall: aptPurge("docker-ce", "docker-ce-cli", "containerd.io", "docker-buildx-plugin"),
+			Run: func(ctx *steps.Context) error {
+				if !ctx.Force && u.IsExecutableInstalled("docker") {
+					return nil
+				}
+				return runScript("https://get.docker.com", "docker-install.sh")
+			},
+		},
+		{
+			ID:        "docker-compose",
+			Title:     "Docker Compose",
+			Tags:      []string{"dev"},
+			Requires:  []string{"docker"},
+			SkipIf:    notWSL,
+			Installed: aptPackage("docker-compose-plugin"),
+			Version:   aptVersion("docker-compose-plugin"),
+			Update:    aptUpgrade("docker-compose-plugin"),
+			Uninstall: aptPurge("docker-compose-plugin"),
+			Run: func(ctx *steps.Context) error {
+				return u.RunCmd("sudo", "apt", "install", "docker-compose-plugin", "-y")
+			},
+		},
+		{
+			ID:        "homebrew",
+			Title:     "Homebrew",
+			Tags:      []string{"dev"},
+			Requires:  []string{"curl"},
+			Installed: existsPath("/home/linuxbrew/.linuxbrew/bin/brew"),
+			Version:   commandVersion("brew", "--version"),
+			// The same as `brew shellenv` prints
+			Env: &steps.Env{
+				Path: []string{"/home/linuxbrew/.linuxbrew/bin", "/home/linuxbrew/.linuxbrew/sbin"},
+				Vars: map[string]string{
+					"HOMEBREW_PREFIX":     "/home/linuxbrew/.linuxbrew",
+					"HOMEBREW_CELLAR":     "/home/linuxbrew/.linuxbrew/Cellar",
+					"HOMEBREW_REPOSITORY": "/home/linuxbrew/.linuxbrew/Homebrew",
+				},
+			},
+			Update: command("brew", "update"),
+			Uninstall: func(ctx *steps.Context) error {
+				return runScript("https://raw.githubusercontent.com/Homebrew/install/HEAD/uninstall.sh", "brew-uninstall.sh", "--force")
+			},
+			Run: func(ctx *steps.Context) error {
+				if !ctx.Force && u.IsExecutableInstalled("brew") {
+					return nil
+				}
+				return runScript("https://raw.githubusercontent.com/Homebrew/install/HEAD/install.sh", "brew-install.sh")
+			},
+		},
+		{
+			ID:        "markdownlint",
+			Tags:      []string{"dev"},
+			Requires:  []string{"ruby"},
+			Installed: executable("mdl"),
+			Version:   commandVersion("mdl", "--version"),
+			Update:    command("sudo", "gem", "update", "mdl"),
+			Uninstall: command("sudo", "gem", "uninstall", "mdl", "-x"),
+			Run: func(ctx *steps.Context) error {
+				return u.RunCmd("sudo", "gem", "install", "mdl")
+			},
+		},
+		fromSource(&steps.Step{
+			ID:        "neovim",
+			Title:     "Neovim",
+			Tags:      []string{"dev"},
+			Requires:  []string{"apt-update", "git", "ssh", "nodejs"},
+			Installed: executable("nvim"),
+			Version:   commandVersion("nvim", "--version"),
+			Probes:    []steps.Probe{nvimRunsProbe},
+		}, sourceBuild{
+			Repo:   "git@github.com:neovim/neovim",
+			Dir:    "neovim",
+			Ref:    "stable",
+			Prefix: "/usr/local",
+			Deps:   []string{"ninja-build", "gettext", "cmake", "unzip", "curl", "build-essential"},
+			Build: [][]string{
+				// Start from a clean build directory, CMake caches the old ref's settings
+				{"make", "distclean"},
+				{"make", "CMAKE_BUILD_TYPE=Release", "CMAKE_INSTALL_PREFIX={prefix}"},
+			},
+			Install:  [][]string{{"sudo", "make", "install"}},
+			Manifest: "build/install_manifest.txt",
+		}, command("npm", "install", "-g", "neovim")),
+		{
+			ID:        "ascii-image-converter",
+			Tags:      []string{"dev"},
+			Requires:  []string{"go"},
+			Installed: homePath("go/bin/ascii-image-converter"),
+			Env:       &steps.Env{Path: []string{"~/go/bin"}},
+			Update:    command("go", "install", "github.com/TheZoraiz/ascii-image-converter@latest"),
+			Uninstall: removeHomePaths("go/bin/ascii-image-converter"),
+			Run: func(ctx *steps.Context) error {
+				return u.RunCmd("go", "install", "github.com/TheZoraiz/ascii-image-converter@latest")
+			},
+		},
+		{
+			ID:        "ripgrep",
+			Tags:      []string{"dev"},
+			Requires:  []string{"apt-update"},
+			Installed: executable("rg"),
+			Version:   commandVersion("rg", "--version"),
+			Update:    aptUpgrade("ripgrep"),
+			Uninstall: aptPurge("ripgrep"),
+			Run: func(ctx *steps.Context) error {
+				return u.RunCmd("sudo", "apt", "install", "ripgrep", "-y")
+			},
+		},
+		{
+			ID:        "fzf",
+			Tags:      []string{"dev"},
+			Requires:  []string{"apt-update"},
+			Installed: executable("fzf"),
+			Version:   commandVersion("fzf", "--version"),
+			Update:    aptUpgrade("fzf"),
+			Uninstall: aptPurge("fzf"),
+			Run: func(ctx *steps.Context) error {
+				return u.RunCmd("sudo", "apt", "install", "fzf", "-y")
+			},
+		},
+		{
+			ID:        "bat",
+			Tags:      []string{"dev"},
+			Requires:  []string{"apt-update"},
+			Installed: executable("batcat"),
+			Version:   commandVersion("batcat", "--version"),
+			Update:    aptUpgrade("bat"),
+			Uninstall: all(aptPurge("bat"), removePaths("/usr/bin/bat")),
+			Run: func(ctx *steps.Context) error {
+				if err := u.RunCmd("sudo", "apt", "install", "bat", "-y"); err != nil {
+					return err
+				}
+				if err := u.RunCmd("sudo", "ln", "-s", "/usr/bin/batcat", "/usr/bin/bat"); err != nil {
+					ctx.Warn(err)
+				}
+				return nil
+			},
+		},
+		{
+			ID:        "lynx",
+			Tags:      []string{"dev"},
+			Requires:  []string{"apt-update"},
+			Installed: executable("lynx"),
+			Version:   commandVersion("lynx", "-version"),
+			Update:    aptUpgrade("lynx"),
+			Uninstall: aptPurge("lynx"),
+			Run: func(ctx *steps.Context) error {
+				return u.RunCmd("sudo", "apt", "install", "lynx", "-y")
+			},
+		},
+		{
+			ID:        "lazygit",
+			Tags:      []string{"dev"},
+			Requires:  []string{"homebrew"},
+			Installed: executable("lazygit"),
+			Version:   commandVersion("lazygit", "--version"),
+			Update:    brewUpgrade("lazygit"),
+			Uninstall: brewUninstall("lazygit"),
+			Run: func(ctx *steps.Context) error {
+				if !ctx.Force && u.IsExecutableInstalled("lazygit") {
+					return nil
+				}
+				return u.RunCmd("brew", "install", "lazygit")
+			},
+		},
+		{
+			ID:        "lazydocker",
+			Tags:      []string{"dev"},
+			Requires:  []string{"homebrew"},
+			Installed: executable("lazydocker"),
+			Version:   commandVersion("lazydocker", "--version"),
+			Update:    brewUpgrade("lazydocker"),
+			Uninstall: brewUninstall("lazydocker"),
+			Run: func(ctx *steps.Context) error {
+				if !ctx.Force && u.IsExecutableInstalled("lazydocker") {
+					return nil
+				}
+				return u.RunCmd("brew", "install", "lazydocker")
+			},
+		},
+		{
+			ID:        "nerd-fonts",
+			Title:     "Nerd Fonts",
+			Tags:      []string{"fonts"},
+			Installed: fontsInstalled,
+			Probes:    []steps.Probe{fontsCachedProbe},
+			Plan:      planFonts,
+			Update:    updateNerdFonts,
+			Uninstall: all(removeHomePaths(".local/share/fonts/NerdFonts"), removePaths("/usr/local/share/fonts/NerdFonts"), command("fc-cache", "-f"), resetSettings("nerd-fonts")),
+			Run:       installNerdFonts,
+		},
+	}
+}
@@ -0,0 +1,101 @@
+package components
+
+import (
+	"github.com/timmo001/bootstrap/state"
+	"github.com/timmo001/bootstrap/steps"
+	u "github.com/timmo001/bootstrap/utils"
+)
+
+func aptPurge(pkgs ...string) func(*steps.Context) error {
+	return command("sudo", append(append([]string{"apt", "purge"}, pkgs...), "-y")...)
+}
+
+func brewUninstall(name string) func(*steps.Context) error {
+	return command("brew", "uninstall", name)
+}
+
+func flatpakUninstall(id string) func(*steps.Context) error {
+	return command("flatpak", "uninstall", id, "-y")
+}
+
+func snapRemove(name string) func(*steps.Context) error {
+	return command("sudo", "snap", "remove", name)
+}
+
+// removePaths deletes system paths.
+func removePaths(paths ...string) func(*steps.Context) error {
+	return command("sudo", append([]string{"rm", "-rf"}, paths...)...)
+}
+
+// removeHomePaths deletes paths relative to the home directory.
+func removeHomePaths(paths ...string) func(*steps.Context) error {
+	return func(ctx *steps.Context) error {
+		for _, path := range paths {
+			if err := u.DeleteDir(ctx.Home + "/" + path); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// all runs each function in turn, stopping at the first error.
+func all(fns ...func(*steps.Context) error) func(*steps.Context) error {
+	return func(ctx *steps.Context) error {
+		for _, fn := range fns {
+			if err := fn(ctx); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// gsettingsSet sets a key, first recording the value it replaces against the
+// step so resetSettings can put it back.
+func gsettingsSet(id, schema, key, value string) error {
+	st, err := state.Load()
+	if err != nil {
+		return err
+	}
+
+	recorded := false
+	for _, setting := range st.Settings[id] {
+		recorded = recorded || (setting.Schema == schema && setting.Key == key)
+	}
+	// Only the first value is kept, later runs would record bootstrap's own
+	if !recorded {
+		previous, err := u.CmdOutput("gsettings", "get", schema, key)
+		if err != nil {
+			return err
+		}
+		if st.Settings == nil {
+			st.Settings = map[string][]*state.Setting{}
+		}
+		st.Settings[id] = append(st.Settings[id], &state.Setting{Schema: schema, Key: key, Value: previous})
+		if err := st.Save(); err != nil {
+			return err
+		}
+	}
+
+	return u.RunCmd("gsettings", "set", schema, key, value)
+}
+
+// resetSettings restores the gsettings values recorded for the step.
+func resetSettings(id string) func(*steps.Context) error {
+	return func(ctx *steps.Context) error {
+		st, err := state.Load()
+		if err != nil {
+			return err
+		}
+
+		for _, setting := range st.Settings[id] {
+			if err := u.RunCmd("gsettings", "set", setting.Schema, setting.Key, setting.Value); err != nil {
+				return err
+			}
+		}
+
+		delete(st.Settings, id)
+		return st.Save()
+	}
+}
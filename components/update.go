@@ -0,0 +1,43 @@
+package components
+
+import (
+	"github.com/timmo001/bootstrap/steps"
+	u "github.com/timmo001/bootstrap/utils"
+)
+
+// command returns a step function that runs a single command.
+func command(name string, arg ...string) func(*steps.Context) error {
+	return func(ctx *steps.Context) error {
+		return u.RunCmd(name, arg...)
+	}
+}
+
+func aptUpgrade(pkgs ...string) func(*steps.Context) error {
+	return command("sudo", append(append([]string{"apt", "install", "--only-upgrade"}, pkgs...), "-y")...)
+}
+
+func brewUpgrade(name string) func(*steps.Context) error {
+	return command("brew", "upgrade", name)
+}
+
+func flatpakUpdate(id string) func(*steps.Context) error {
+	return command("flatpak", "update", id, "-y")
+}
+
+func snapRefresh(name string) func(*steps.Context) error {
+	return command("sudo", "snap", "refresh", name)
+}
+
+// runScript downloads an install script, runs it and deletes it again.
+func runScript(url, file string, arg ...string) error {
+	if err := u.DownloadFile(url, file); err != nil {
+		return err
+	}
+	if err := u.RunCmd("chmod", "+x", file); err != nil {
+		return err
+	}
+	if err := u.RunCmd("./"+file, arg...); err != nil {
+		return err
+	}
+	return u.DeleteFile(file)
+}
@@ -0,0 +1,278 @@
+package dotfiles
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/log"
+
+	"github.com/timmo001/bootstrap/backups"
+)
+
+// What to do when a real file is where a link should go.
+const (
+	ConflictFail   = "fail"
+	ConflictAdopt  = "adopt"
+	ConflictBackup = "backup"
+)
+
+const (
+	ActionMkdir  = "mkdir"
+	ActionLink   = "link"
+	ActionAdopt  = "adopt"
+	ActionBackup = "backup"
+	ActionUnlink = "unlink"
+)
+
+// Like stow, these are never linked, and the docs only at a package's root
+var (
+	ignore     = regexp.MustCompile(`^(\.git|\.gitignore|\.gitmodules|\.stow-local-ignore)$`)
+	ignoreRoot = regexp.MustCompile(`^(README.*|LICENSE.*|COPYING)$`)
+)
+
+// Linker links packages from a dotfiles repo into a target directory the
+// way GNU stow does: every file in a package is symlinked to the same path
+// under the target, with relative links. Directories are created rather
+// than folded into links, but links stow folded are recognised.
+type Linker struct {
+	// Dir is the dotfiles repo, each top-level directory is a package
+	Dir string
+	// Target is usually the home directory
+	Target string
+	// Packages to link, or every package when empty
+	Packages []string
+	// Conflict is one of ConflictFail, ConflictAdopt or ConflictBackup
+	Conflict string
+}
+
+// Action is a single change to the target directory.
+type Action struct {
+	Kind   string
+	Target string
+	// Source is the file in the package, for links and adoptions
+	Source string
+}
+
+func (a Action) String() string {
+	switch a.Kind {
+	case ActionLink:
+		return fmt.Sprintf("link %s -> %s", a.Target, a.Source)
+	case ActionAdopt:
+		return fmt.Sprintf("adopt %s into %s", a.Target, a.Source)
+	case ActionBackup:
+		return fmt.Sprintf("back up %s", a.Target)
+	default:
+		return a.Kind + " " + a.Target
+	}
+}
+
+// ConflictError lists the files in the way of links. Dirs are directories
+// where a file should be linked, which can't be adopted or backed up.
+type ConflictError struct {
+	Paths []string
+	Dirs  []string
+}
+
+func (e *ConflictError) Error() string {
+	var problems []string
+	if len(e.Paths) > 0 {
+		problems = append(problems, fmt.Sprintf("%d existing files are in the way, adopt or back them up: %s", len(e.Paths), strings.Join(e.Paths, ", ")))
+	}
+	if len(e.Dirs) > 0 {
+		problems = append(problems, fmt.Sprintf("%d directories are in the way of files, move them aside: %s", len(e.Dirs), strings.Join(e.Dirs, ", ")))
+	}
+	return strings.Join(problems, "; ")
+}
+
+// AllPackages lists the packages in the repo.
+func (l *Linker) AllPackages() ([]string, error) {
+	entries, err := os.ReadDir(l.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var packages []string
+	for _, e := range entries {
+		if e.IsDir() && !strings.HasPrefix(e.Name(), ".") {
+			packages = append(packages, e.Name())
+		}
+	}
+	return packages, nil
+}
+
+func (l *Linker) packages() ([]string, error) {
+	all, err := l.AllPackages()
+	if err != nil || len(l.Packages) == 0 {
+		return all, err
+	}
+
+	for _, p := range l.Packages {
+		if !slices.Contains(all, p) {
+			return nil, fmt.Errorf("no package %s in %s", p, l.Dir)
+		}
+	}
+	return l.Packages, nil
+}
+
+// Plan works out the actions needed to link the packages. Conflicts are an
+// error unless the conflict policy resolves them.
+func (l *Linker) Plan() ([]Action, error) {
+	packages, err := l.packages()
+	if err != nil {
+		return nil, err
+	}
+
+	var actions []Action
+	var conflicts, dirs []string
+	mkdirs := map[string]bool{}
+	for _, p := range packages {
+		root := filepath.Join(l.Dir, p)
+		err := filepath.WalkDir(root, func(source string, d os.DirEntry, err error) error {
+			if err != nil || source == root {
+				return err
+			}
+			rel, _ := filepath.Rel(root, source)
+			if ignore.MatchString(d.Name()) || (rel == d.Name() && ignoreRoot.MatchString(d.Name())) {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+
+			target := filepath.Join(l.Target, rel)
+			info, statErr := os.Lstat(target)
+
+			if d.IsDir() {
+				switch {
+				case os.IsNotExist(statErr):
+					if !mkdirs[target] {
+						mkdirs[target] = true
+						actions = append(actions, Action{Kind: ActionMkdir, Target: target})
+					}
+				case info.Mode()&os.ModeSymlink != 0 && linksTo(target, source):
+					// Folded by stow, everything below is linked already
+					return filepath.SkipDir
+				case !info.IsDir():
+					conflicts = append(conflicts, target)
+					return filepath.SkipDir
+				}
+				return nil
+			}
+
+			switch {
+			case os.IsNotExist(statErr):
+			case info.Mode()&os.ModeSymlink != 0 && linksTo(target, source):
+				return nil
+			case l.Conflict == ConflictAdopt && info.Mode().IsRegular():
+				actions = append(actions, Action{Kind: ActionAdopt, Target: target, Source: source})
+			case info.IsDir():
+				dirs = append(dirs, target)
+				return nil
+			case l.Conflict == ConflictBackup:
+				actions = append(actions, Action{Kind: ActionBackup, Target: target})
+			default:
+				conflicts = append(conflicts, target)
+				return nil
+			}
+			actions = append(actions, Action{Kind: ActionLink, Target: target, Source: source})
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(conflicts) > 0 || len(dirs) > 0 {
+		return actions, &ConflictError{Paths: conflicts, Dirs: dirs}
+	}
+	return actions, nil
+}
+
+// UnlinkPlan works out the actions needed to remove the packages' links,
+// including directory links stow folded.
+func (l *Linker) UnlinkPlan() ([]Action, error) {
+	packages, err := l.packages()
+	if err != nil {
+		return nil, err
+	}
+
+	var actions []Action
+	for _, p := range packages {
+		root := filepath.Join(l.Dir, p)
+		err := filepath.WalkDir(root, func(source string, d os.DirEntry, err error) error {
+			if err != nil || source == root {
+				return err
+			}
+			rel, _ := filepath.Rel(root, source)
+			target := filepath.Join(l.Target, rel)
+			info, statErr := os.Lstat(target)
+			if statErr != nil || info.Mode()&os.ModeSymlink == 0 {
+				return nil
+			}
+			if linksTo(target, source) {
+				actions = append(actions, Action{Kind: ActionUnlink, Target: target})
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return actions, nil
+}
+
+// Apply carries out the actions in order.
+func Apply(actions []Action) error {
+	for _, a := range actions {
+		log.Infof("Dotfiles: %s", a)
+
+		var err error
+		switch a.Kind {
+		case ActionMkdir:
+			err = os.MkdirAll(a.Target, 0755)
+		case ActionLink:
+			var rel string
+			if rel, err = filepath.Rel(filepath.Dir(a.Target), a.Source); err == nil {
+				err = os.Symlink(rel, a.Target)
+			}
+		case ActionAdopt:
+			// Like stow --adopt, the existing file replaces the package's copy
+			if err = backups.Save(a.Target); err == nil {
+				err = os.Rename(a.Target, a.Source)
+			}
+		case ActionBackup:
+			if err = backups.Save(a.Target); err == nil {
+				err = os.Remove(a.Target)
+			}
+		case ActionUnlink:
+			if err = backups.Save(a.Target); err == nil {
+				err = os.Remove(a.Target)
+			}
+		default:
+			err = fmt.Errorf("unknown action %q", a.Kind)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// linksTo reports whether the symlink at target points at source.
+func linksTo(target, source string) bool {
+	dest, err := os.Readlink(target)
+	if err != nil {
+		return false
+	}
+	if !filepath.IsAbs(dest) {
+		dest = filepath.Join(filepath.Dir(target), dest)
+	}
+	return filepath.Clean(dest) == filepath.Clean(source)
+}
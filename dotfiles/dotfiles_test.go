@@ -0,0 +1,185 @@
+package dotfiles
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func writeFile(t *testing.T, file, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// testLinker makes a repo with a zsh package, and an empty target.
+func testLinker(t *testing.T, conflict string) *Linker {
+	t.Helper()
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	l := &Linker{Dir: t.TempDir(), Target: t.TempDir(), Conflict: conflict}
+	for _, file := range []string{
+		"zsh/.zshrc",
+		"zsh/README.md",
+		"zsh/.gitignore",
+		"zsh/.config/zsh/aliases.zsh",
+		"zsh/.config/zsh/README.md",
+		"zsh/.git/config",
+	} {
+		writeFile(t, filepath.Join(l.Dir, file), file)
+	}
+	return l
+}
+
+func kinds(actions []Action, target string) []string {
+	var out []string
+	for _, a := range actions {
+		rel, _ := filepath.Rel(target, a.Target)
+		out = append(out, a.Kind+" "+rel)
+	}
+	return out
+}
+
+func TestPlan(t *testing.T) {
+	tests := []struct {
+		name     string
+		conflict string
+		// setup puts things in the target before planning
+		setup     func(t *testing.T, l *Linker)
+		want      []string
+		wantPaths int
+		wantDirs  int
+	}{
+		{
+			name: "fresh target",
+			want: []string{"mkdir .config", "mkdir .config/zsh", "link .config/zsh/README.md", "link .config/zsh/aliases.zsh", "link .zshrc"},
+		},
+		{
+			name: "already linked",
+			setup: func(t *testing.T, l *Linker) {
+				actions, _ := l.Plan()
+				if err := Apply(actions); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+		{
+			name: "folded by stow",
+			setup: func(t *testing.T, l *Linker) {
+				if err := os.Symlink(filepath.Join(l.Dir, "zsh/.config"), filepath.Join(l.Target, ".config")); err != nil {
+					t.Fatal(err)
+				}
+			},
+			want: []string{"link .zshrc"},
+		},
+		{
+			name:      "file in the way",
+			conflict:  ConflictFail,
+			setup:     func(t *testing.T, l *Linker) { writeFile(t, filepath.Join(l.Target, ".zshrc"), "mine") },
+			want:      []string{"mkdir .config", "mkdir .config/zsh", "link .config/zsh/README.md", "link .config/zsh/aliases.zsh"},
+			wantPaths: 1,
+		},
+		{
+			name:     "adopt",
+			conflict: ConflictAdopt,
+			setup:    func(t *testing.T, l *Linker) { writeFile(t, filepath.Join(l.Target, ".zshrc"), "mine") },
+			want:     []string{"mkdir .config", "mkdir .config/zsh", "link .config/zsh/README.md", "link .config/zsh/aliases.zsh", "adopt .zshrc", "link .zshrc"},
+		},
+		{
+			name:     "backup",
+			conflict: ConflictBackup,
+			setup:    func(t *testing.T, l *Linker) { writeFile(t, filepath.Join(l.Target, ".zshrc"), "mine") },
+			want:     []string{"mkdir .config", "mkdir .config/zsh", "link .config/zsh/README.md", "link .config/zsh/aliases.zsh", "backup .zshrc", "link .zshrc"},
+		},
+		{
+			name:     "directory in the way",
+			conflict: ConflictBackup,
+			setup: func(t *testing.T, l *Linker) {
+				writeFile(t, filepath.Join(l.Target, ".zshrc", "inside"), "mine")
+			},
+			want:     []string{"mkdir .config", "mkdir .config/zsh", "link .config/zsh/README.md", "link .config/zsh/aliases.zsh"},
+			wantDirs: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := testLinker(t, tt.conflict)
+			if tt.setup != nil {
+				tt.setup(t, l)
+			}
+
+			actions, err := l.Plan()
+			var conflict *ConflictError
+			switch {
+			case tt.wantPaths+tt.wantDirs == 0 && err != nil:
+				t.Fatal(err)
+			case tt.wantPaths+tt.wantDirs > 0 && !errors.As(err, &conflict):
+				t.Fatalf("err = %v, want a conflict", err)
+			case conflict != nil && (len(conflict.Paths) != tt.wantPaths || len(conflict.Dirs) != tt.wantDirs):
+				t.Errorf("conflict = %+v, want %d paths and %d dirs", conflict, tt.wantPaths, tt.wantDirs)
+			}
+			if got := kinds(actions, l.Target); !slices.Equal(got, tt.want) {
+				t.Errorf("actions = %q, want %q", got, tt.want)
+			}
+			if conflict != nil {
+				return
+			}
+
+			if err := Apply(actions); err != nil {
+				t.Fatal(err)
+			}
+			if again, err := l.Plan(); err != nil || len(again) > 0 {
+				t.Errorf("second plan = %q, %v, want nothing to do", kinds(again, l.Target), err)
+			}
+		})
+	}
+}
+
+func TestAdoptKeepsTheUsersFile(t *testing.T) {
+	l := testLinker(t, ConflictAdopt)
+	writeFile(t, filepath.Join(l.Target, ".zshrc"), "mine")
+	actions, err := l.Plan()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Apply(actions); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(l.Target, ".zshrc"))
+	if err != nil || string(data) != "mine" {
+		t.Errorf(".zshrc = %q, %v, want the adopted file", data, err)
+	}
+}
+
+func TestUnlinkPlan(t *testing.T) {
+	l := testLinker(t, ConflictFail)
+	actions, err := l.Plan()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Apply(actions); err != nil {
+		t.Fatal(err)
+	}
+	// A file of the user's next to the links is left alone
+	writeFile(t, filepath.Join(l.Target, ".config/zsh/local.zsh"), "mine")
+
+	unlink, err := l.UnlinkPlan()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"unlink .config/zsh/README.md", "unlink .config/zsh/aliases.zsh", "unlink .zshrc"}
+	if got := kinds(unlink, l.Target); !slices.Equal(got, want) {
+		t.Errorf("actions = %q, want %q", got, want)
+	}
+	if err := Apply(unlink); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(l.Target, ".config/zsh/local.zsh")); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,78 @@
+package events
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+const (
+	StepStarted      = "step_started"
+	StepFinished     = "step_finished"
+	CommandStarted   = "command_started"
+	CommandFinished  = "command_finished"
+	DownloadProgress = "download_progress"
+)
+
+// Event is a single line of the JSON Lines event stream.
+type Event struct {
+	Time       time.Time `json:"time"`
+	Type       string    `json:"type"`
+	Step       string    `json:"step,omitempty"`
+	Status     string    `json:"status,omitempty"`
+	Command    string    `json:"command,omitempty"`
+	Args       []string  `json:"args,omitempty"`
+	Dir        string    `json:"dir,omitempty"`
+	ExitCode   *int      `json:"exit_code,omitempty"`
+	DurationMS *int64    `json:"duration_ms,omitempty"`
+	URL        string    `json:"url,omitempty"`
+	Bytes      int64     `json:"bytes,omitempty"`
+	Total      int64     `json:"total,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
+
+var (
+	mu   sync.Mutex
+	enc  *json.Encoder
+	step string
+)
+
+// SetOutput starts writing events to w. Until it is called events are dropped.
+func SetOutput(w io.Writer) {
+	mu.Lock()
+	defer mu.Unlock()
+	enc = json.NewEncoder(w)
+}
+
+// SetStep sets the step that following events are attributed to.
+func SetStep(id string) {
+	mu.Lock()
+	defer mu.Unlock()
+	step = id
+}
+
+func Emit(e Event) {
+	mu.Lock()
+	defer mu.Unlock()
+	if enc == nil {
+		return
+	}
+
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	if e.Step == "" {
+		e.Step = step
+	}
+	_ = enc.Encode(e)
+}
+
+func Duration(d time.Duration) *int64 {
+	ms := d.Milliseconds()
+	return &ms
+}
+
+func ExitCode(code int) *int {
+	return &code
+}
@@ -19,6 +19,7 @@ echo "Go version:        " $GO_VERSION
 # If -s flag is passed, skip init
 if [[ "$1" == "-s" ]]; then
   echo "Skipping init"
+  shift
 else
   echo "Setup rc files"
   touch ~/.bashrc
@@ -39,14 +40,12 @@ else
     echo "Installing go"
     sudo tar -C /usr/local -xzf $filename
 
-    # Check PATH variable
+    # Check PATH variable, the shell-rc step adds go to the rc files
     if [[ ":$PATH:" == *":/usr/local/go/bin:"* ]]; then
       echo "go is already in PATH"
     else
-      # Add go to PATH
       echo "Adding go to PATH"
-      echo "export PATH=\$PATH:/usr/local/go/bin" >>~/.bashrc
-      echo "export PATH=\$PATH:/usr/local/go/bin" >>~/.zshrc
+      export PATH=$PATH:/usr/local/go/bin
     fi
 
     # Remove downloaded file
@@ -101,8 +100,8 @@ fi
 echo "running go mod tidy"
 go mod tidy
 
-echo "running go run app/bootstrap.go"
-go run app/bootstrap.go
+echo "running go run ./app"
+go run ./app "$@"
 
 set +e
 echo "source ~/.$CURRENT_SHELL"rc""
@@ -0,0 +1,71 @@
+package state
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Run is a single invocation of bootstrap, with its own directory for logs.
+type Run struct {
+	ID  string
+	Dir string
+}
+
+func RunsDir() string {
+	return filepath.Join(Dir(), "runs")
+}
+
+func NewRun() (*Run, error) {
+	id := time.Now().Format("20060102-150405")
+	run := &Run{ID: id, Dir: filepath.Join(RunsDir(), id)}
+	if err := os.MkdirAll(run.Dir, 0755); err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+// Runs returns the IDs of all recorded runs, oldest first.
+func Runs() ([]string, error) {
+	entries, err := os.ReadDir(RunsDir())
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	for _, e := range entries {
+		if e.IsDir() {
+			ids = append(ids, e.Name())
+		}
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// GetRun returns the run with the given ID, or the latest run if id is empty.
+func GetRun(id string) (*Run, error) {
+	if id == "" {
+		ids, err := Runs()
+		if err != nil {
+			return nil, err
+		}
+		if len(ids) == 0 {
+			return nil, fmt.Errorf("no runs recorded in %s", RunsDir())
+		}
+		id = ids[len(ids)-1]
+	}
+
+	run := &Run{ID: id, Dir: filepath.Join(RunsDir(), id)}
+	if _, err := os.Stat(run.Dir); err != nil {
+		return nil, fmt.Errorf("run %s not found", id)
+	}
+	return run, nil
+}
+
+func (r *Run) LogPath(step string) string {
+	return filepath.Join(r.Dir, step+".log")
+}
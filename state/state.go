@@ -0,0 +1,119 @@
+package state
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// State is persisted between runs in the bootstrap state directory.
+type State struct {
+	// Selection is the list of steps last ticked in the component picker
+	Selection []string `json:"selection,omitempty"`
+	// Pins overrides the ref a source build or checkout uses, or the release
+	// version a step installs, by step ID
+	Pins map[string]string `json:"pins,omitempty"`
+	// Builds records what each source build last installed, by step ID
+	Builds map[string]*Build `json:"builds,omitempty"`
+	// Settings records the gsettings values each step replaced, by step ID
+	Settings map[string][]*Setting `json:"settings,omitempty"`
+	// Releases records the GitHub release each step last installed, by step ID
+	Releases map[string]*Release `json:"releases,omitempty"`
+	// Archives records the download each archive app was last installed
+	// from, by step ID
+	Archives map[string]*Archive `json:"archives,omitempty"`
+}
+
+// Archive is the download an archive app was installed from.
+type Archive struct {
+	URL string `json:"url"`
+	// Fingerprint identifies what the URL served, see utils.RemoteFingerprint
+	Fingerprint string    `json:"fingerprint"`
+	Version     string    `json:"version"`
+	Installed   time.Time `json:"installed"`
+}
+
+// Release is the GitHub release asset a step installed.
+type Release struct {
+	Repo      string    `json:"repo"`
+	Tag       string    `json:"tag"`
+	Asset     string    `json:"asset"`
+	Installed time.Time `json:"installed"`
+}
+
+// Setting is a gsettings key and the value it had before a step changed it.
+type Setting struct {
+	Schema string `json:"schema"`
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+}
+
+// Build is the ledger entry for a component built from source.
+type Build struct {
+	Repo    string    `json:"repo"`
+	Ref     string    `json:"ref"`
+	Commit  string    `json:"commit"`
+	Prefix  string    `json:"prefix"`
+	Version string    `json:"version,omitempty"`
+	Built   time.Time `json:"built"`
+	// Files lists everything the install put on disk
+	Files []string `json:"files,omitempty"`
+	// Dirs lists the directories under Prefix the install created, the only
+	// ones removed again when they are left empty
+	Dirs []string `json:"dirs,omitempty"`
+}
+
+// Dir returns $XDG_STATE_HOME/bootstrap, falling back to ~/.local/state/bootstrap.
+func Dir() string {
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, "bootstrap")
+	}
+	return filepath.Join(os.Getenv("HOME"), ".local", "state", "bootstrap")
+}
+
+// CacheDir returns $XDG_CACHE_HOME/bootstrap, falling back to ~/.cache/bootstrap.
+func CacheDir() string {
+	if dir := os.Getenv("XDG_CACHE_HOME"); dir != "" {
+		return filepath.Join(dir, "bootstrap")
+	}
+	return filepath.Join(os.Getenv("HOME"), ".cache", "bootstrap")
+}
+
+func path() string {
+	return filepath.Join(Dir(), "state.json")
+}
+
+func Load() (*State, error) {
+	s := &State{}
+
+	data, err := os.ReadFile(path())
+	if os.IsNotExist(err) {
+		return s, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *State) Save() error {
+	if err := os.MkdirAll(Dir(), 0755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so a failed write never truncates the state
+	tmp := path() + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path())
+}
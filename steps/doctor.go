@@ -0,0 +1,92 @@
+package steps
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	ProbePass = "pass"
+	ProbeWarn = "warn"
+	ProbeFail = "fail"
+)
+
+// Probe verifies one aspect of a component after it has been installed.
+type Probe struct {
+	Name  string
+	Check func(ctx *Context) ProbeResult
+}
+
+type ProbeResult struct {
+	Status  string
+	Message string
+	// Hint tells the user how to fix a warning or failure
+	Hint string
+}
+
+func Pass(format string, a ...any) ProbeResult {
+	return ProbeResult{Status: ProbePass, Message: fmt.Sprintf(format, a...)}
+}
+
+func Warn(hint, format string, a ...any) ProbeResult {
+	return ProbeResult{Status: ProbeWarn, Message: fmt.Sprintf(format, a...), Hint: hint}
+}
+
+func Fail(hint, format string, a ...any) ProbeResult {
+	return ProbeResult{Status: ProbeFail, Message: fmt.Sprintf(format, a...), Hint: hint}
+}
+
+type DoctorResult struct {
+	Step  string
+	Probe string
+	ProbeResult
+}
+
+// probes returns the step's own probes, after one checking it's installed
+// when it has an Installed check.
+func (s *Step) probes() []Probe {
+	if s.Installed == nil {
+		return s.Probes
+	}
+	return append([]Probe{{
+		Name: "installed",
+		Check: func(ctx *Context) ProbeResult {
+			if s.Installed(ctx) {
+				return Pass("ok")
+			}
+			return Fail("run bootstrap --only "+s.ID, "missing")
+		},
+	}}, s.Probes...)
+}
+
+// Doctor runs every probe of the given steps.
+func Doctor(ctx *Context, steps []*Step) []DoctorResult {
+	var results []DoctorResult
+	for _, s := range steps {
+		for _, p := range s.probes() {
+			results = append(results, DoctorResult{Step: s.ID, Probe: p.Name, ProbeResult: p.Check(ctx)})
+		}
+	}
+	return results
+}
+
+var probeStyles = map[string]lipgloss.Style{
+	ProbePass: lipgloss.NewStyle().Foreground(lipgloss.Color("10")),
+	ProbeWarn: lipgloss.NewStyle().Foreground(lipgloss.Color("11")),
+	ProbeFail: lipgloss.NewStyle().Foreground(lipgloss.Color("9")),
+}
+
+var probeIcons = map[string]string{
+	ProbePass: "✓",
+	ProbeWarn: "!",
+	ProbeFail: "✗",
+}
+
+func FormatDoctorResult(r DoctorResult) string {
+	line := probeStyles[r.Status].Render(fmt.Sprintf("%s %s: %s", probeIcons[r.Status], r.Step, r.Probe)) + " - " + r.Message
+	if r.Hint != "" {
+		line += "\n    hint: " + r.Hint
+	}
+	return line
+}
@@ -0,0 +1,126 @@
+package steps
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	u "github.com/timmo001/bootstrap/utils"
+)
+
+// Env is what a component needs in the environment to be usable. Paths
+// starting with "~/" are relative to the home directory.
+type Env struct {
+	// Path lists directories to put on PATH
+	Path []string
+	// Vars are exported as they are
+	Vars map[string]string
+	// Shell lines are added to the env file for interactive shells only,
+	// such as hooks printed by the tool itself
+	Shell []string
+}
+
+// EnvFile returns $XDG_CONFIG_HOME/bootstrap/env.sh, falling back to
+// ~/.config/bootstrap/env.sh. The shell rc files source it.
+func EnvFile(ctx *Context) string {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "bootstrap", "env.sh")
+	}
+	return filepath.Join(ctx.Home, ".config", "bootstrap", "env.sh")
+}
+
+// ApplyEnv adds the step's environment to this process, so later commands
+// in the run can find what it installs.
+func ApplyEnv(ctx *Context, s *Step) {
+	if s.Env == nil {
+		return
+	}
+	for _, dir := range s.Env.Path {
+		dir = expandHome(ctx, dir)
+		if !strings.Contains(":"+os.Getenv("PATH")+":", ":"+dir+":") {
+			os.Setenv("PATH", dir+":"+os.Getenv("PATH"))
+		}
+	}
+	for _, name := range sortedKeys(s.Env.Vars) {
+		os.Setenv(name, expandHome(ctx, s.Env.Vars[name]))
+	}
+}
+
+// ApplyInstalledEnv applies the environment of every installed step, for
+// tools installed by earlier runs in shells that haven't picked them up.
+func ApplyInstalledEnv(ctx *Context, steps []*Step) {
+	for _, s := range steps {
+		if s.Env != nil && s.Installed != nil && s.Installed(ctx) {
+			ApplyEnv(ctx, s)
+		}
+	}
+}
+
+// WriteEnvFile writes the env file for zsh and bash from the environment of
+// every installed step.
+func WriteEnvFile(ctx *Context, steps []*Step) error {
+	var paths, vars, shell []string
+	seen := map[string]bool{}
+	for _, s := range steps {
+		if s.Env == nil || (s.Installed != nil && !s.Installed(ctx)) {
+			continue
+		}
+		for _, dir := range s.Env.Path {
+			if !seen[dir] {
+				seen[dir] = true
+				paths = append(paths, fmt.Sprintf("bootstrap_path %s", shellHome(dir)))
+			}
+		}
+		for _, name := range sortedKeys(s.Env.Vars) {
+			vars = append(vars, fmt.Sprintf("export %s=%s", name, shellHome(s.Env.Vars[name])))
+		}
+		shell = append(shell, s.Env.Shell...)
+	}
+
+	lines := []string{
+		"# Generated by bootstrap, changes are overwritten on the next run",
+		"",
+		`bootstrap_path() { case ":$PATH:" in *":$1:"*) ;; *) PATH="$1:$PATH" ;; esac; }`,
+	}
+	lines = append(lines, paths...)
+	lines = append(lines, "export PATH", "unset -f bootstrap_path")
+	if len(vars) > 0 {
+		lines = append(append(lines, ""), vars...)
+	}
+	if len(shell) > 0 {
+		lines = append(append(lines, "", "# Interactive shells only", `case $- in *i*)`), shell...)
+		lines = append(lines, ";; esac")
+	}
+
+	file := EnvFile(ctx)
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	return u.WriteFileAtomic(file, []byte(strings.Join(lines, "\n")+"\n"))
+}
+
+func expandHome(ctx *Context, path string) string {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		return filepath.Join(ctx.Home, rest)
+	}
+	return path
+}
+
+// shellHome quotes a path for the env file, keeping $HOME in it.
+func shellHome(path string) string {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		return `"$HOME/` + rest + `"`
+	}
+	return `"` + path + `"`
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
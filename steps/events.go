@@ -0,0 +1,33 @@
+package steps
+
+import (
+	"time"
+
+	"github.com/timmo001/bootstrap/events"
+)
+
+// EventEmitter reports each step on the event stream and attributes the
+// command events in between to it.
+type EventEmitter struct {
+	started time.Time
+}
+
+func (e *EventEmitter) StepStarted(s *Step) {
+	e.started = time.Now()
+	events.SetStep(s.ID)
+	events.Emit(events.Event{Type: events.StepStarted})
+}
+
+func (e *EventEmitter) StepFinished(s *Step, err error) {
+	ev := events.Event{
+		Type:       events.StepFinished,
+		Status:     "done",
+		DurationMS: events.Duration(time.Since(e.started)),
+	}
+	if err != nil {
+		ev.Status = "failed"
+		ev.Error = err.Error()
+	}
+	events.Emit(ev)
+	events.SetStep("")
+}
@@ -0,0 +1,63 @@
+package steps
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/charmbracelet/log"
+
+	u "github.com/timmo001/bootstrap/utils"
+)
+
+// LogCapture tees each step's output into Dir/<step>.log and prints the end
+// of the log when a step fails.
+type LogCapture struct {
+	Dir   string
+	Lines int
+
+	file           *os.File
+	stdout, stderr io.Writer
+}
+
+func (c *LogCapture) path(s *Step) string {
+	return filepath.Join(c.Dir, s.ID+".log")
+}
+
+func (c *LogCapture) StepStarted(s *Step) {
+	f, err := os.Create(c.path(s))
+	if err != nil {
+		log.Warnf("Could not capture output of %s: %v", s.ID, err)
+		return
+	}
+
+	c.file = f
+	c.stdout, c.stderr = u.Stdout, u.Stderr
+	w := u.PlainWriter(f)
+	u.Stdout = io.MultiWriter(c.stdout, w)
+	u.Stderr = io.MultiWriter(c.stderr, w)
+}
+
+func (c *LogCapture) StepFinished(s *Step, err error) {
+	if c.file == nil {
+		return
+	}
+	u.Stdout, u.Stderr = c.stdout, c.stderr
+	c.file.Close()
+	c.file = nil
+
+	if err == nil {
+		return
+	}
+
+	lines, tailErr := u.TailFile(c.path(s), c.Lines)
+	if tailErr != nil {
+		log.Warnf("Could not read log of %s: %v", s.ID, tailErr)
+		return
+	}
+	log.Errorf("%s failed, last %d lines of %s:", s.ID, len(lines), c.path(s))
+	for _, line := range lines {
+		fmt.Fprintln(u.Stderr, "  "+line)
+	}
+}
@@ -0,0 +1,66 @@
+package steps
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/huh"
+)
+
+// Pick shows the steps grouped by category with their install state and
+// returns the IDs the user ticked, in run order. Steps in selected are ticked
+// up front; when selected is empty everything is ticked.
+func (r *Registry) Pick(ctx *Context, selected []string) ([]string, error) {
+	preselect := toSet(selected)
+
+	var groups []*huh.Group
+	var values []*[]string
+	for _, category := range r.Categories() {
+		var options []huh.Option[string]
+		value := []string{}
+		for _, s := range r.steps {
+			if s.Category != category {
+				continue
+			}
+			// Hide steps that can't run here, such as desktop apps on a server
+			if s.SkipIf != nil && s.SkipIf(ctx) != "" {
+				continue
+			}
+
+			options = append(options, huh.NewOption(fmt.Sprintf("%-26s %s", s.ID, s.State(ctx)), s.ID))
+			if len(selected) == 0 || preselect[s.ID] {
+				value = append(value, s.ID)
+			}
+		}
+		if len(options) == 0 {
+			continue
+		}
+
+		values = append(values, &value)
+		groups = append(groups, huh.NewGroup(
+			huh.NewMultiSelect[string]().
+				Title(category).
+				Description("Select the components to apply").
+				Value(&value).
+				Options(options...),
+		))
+	}
+
+	if err := huh.NewForm(groups...).Run(); err != nil {
+		return nil, err
+	}
+
+	picked := map[string]bool{}
+	for _, value := range values {
+		for _, id := range *value {
+			picked[id] = true
+		}
+	}
+
+	var ids []string
+	for _, s := range r.steps {
+		if picked[s.ID] {
+			ids = append(ids, s.ID)
+		}
+	}
+	return ids, nil
+}
@@ -0,0 +1,32 @@
+package steps
+
+import (
+	"github.com/charmbracelet/log"
+
+	u "github.com/timmo001/bootstrap/utils"
+)
+
+// PrintPlan shows what each step would change, without running anything.
+func PrintPlan(ctx *Context, steps []*Step) error {
+	u.PrintSeparator("Plan")
+	for _, s := range steps {
+		if s.Plan == nil {
+			log.Infof("%-26s would run", s.ID)
+			continue
+		}
+
+		changes, err := s.Plan(ctx)
+		if err != nil {
+			return err
+		}
+		if len(changes) == 0 {
+			log.Infof("%-26s no changes", s.ID)
+			continue
+		}
+		log.Infof("%-26s %d changes", s.ID, len(changes))
+		for _, change := range changes {
+			log.Infof("    %s", change)
+		}
+	}
+	return nil
+}
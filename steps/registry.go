@@ -0,0 +1,73 @@
+package steps
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Registry keeps steps in the order they were added, which is the order they run in.
+type Registry struct {
+	steps []*Step
+	byID  map[string]*Step
+}
+
+func NewRegistry() *Registry {
+	return &Registry{byID: map[string]*Step{}}
+}
+
+func (r *Registry) Add(steps ...*Step) {
+	for _, s := range steps {
+		if _, exists := r.byID[s.ID]; exists {
+			panic(fmt.Sprintf("step %q registered twice", s.ID))
+		}
+		// Dependencies must be registered first so the run order satisfies them
+		for _, dep := range s.Requires {
+			if _, exists := r.byID[dep]; !exists {
+				panic(fmt.Sprintf("step %q requires %q which is not registered before it", s.ID, dep))
+			}
+		}
+		if s.Title == "" {
+			s.Title = s.ID
+		}
+
+		r.steps = append(r.steps, s)
+		r.byID[s.ID] = s
+	}
+}
+
+func (r *Registry) Get(id string) (*Step, bool) {
+	s, ok := r.byID[id]
+	return s, ok
+}
+
+func (r *Registry) Steps() []*Step {
+	return r.steps
+}
+
+// Categories returns the categories in the order they first appear.
+func (r *Registry) Categories() []string {
+	seen := map[string]bool{}
+	var categories []string
+	for _, s := range r.steps {
+		if !seen[s.Category] {
+			seen[s.Category] = true
+			categories = append(categories, s.Category)
+		}
+	}
+	return categories
+}
+
+func (r *Registry) Tags() []string {
+	seen := map[string]bool{}
+	var tags []string
+	for _, s := range r.steps {
+		for _, t := range s.Tags {
+			if !seen[t] {
+				seen[t] = true
+				tags = append(tags, t)
+			}
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
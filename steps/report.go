@@ -0,0 +1,239 @@
+package steps
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/lipgloss/table"
+)
+
+const (
+	StatusInstalled = "installed"
+	StatusUpdated   = "updated"
+	StatusUnchanged = "unchanged"
+	StatusRemoved   = "removed"
+	StatusSkipped   = "skipped"
+	StatusFailed    = "failed"
+)
+
+type ReportEntry struct {
+	Step            string   `json:"step"`
+	Title           string   `json:"title"`
+	Status          string   `json:"status"`
+	Reason          string   `json:"reason,omitempty"`
+	Version         string   `json:"version,omitempty"`
+	PreviousVersion string   `json:"previous_version,omitempty"`
+	DurationMS      int64    `json:"duration_ms"`
+	Error           string   `json:"error,omitempty"`
+	Warnings        []string `json:"warnings,omitempty"`
+	Changes         []string `json:"changes,omitempty"`
+}
+
+// Report records the outcome of every step in a run. It observes the run to
+// work out whether each step installed, updated or left its component alone.
+type Report struct {
+	RunID    string         `json:"run_id"`
+	Started  time.Time      `json:"started"`
+	Finished time.Time      `json:"finished"`
+	Entries  []*ReportEntry `json:"steps"`
+
+	ctx          *Context
+	byID         map[string]*ReportEntry
+	wasInstalled bool
+	stepStarted  time.Time
+}
+
+func NewReport(ctx *Context, runID string, selection *Selection) *Report {
+	r := &Report{
+		RunID:   runID,
+		Started: time.Now(),
+		ctx:     ctx,
+		byID:    map[string]*ReportEntry{},
+	}
+	for _, d := range selection.Decisions {
+		e := &ReportEntry{Step: d.Step.ID, Title: d.Step.Title, Status: StatusSkipped, Reason: d.Reason}
+		if d.Included {
+			// Overwritten when the step runs
+			e.Reason = "not reached"
+		}
+		r.Entries = append(r.Entries, e)
+		r.byID[e.Step] = e
+	}
+	return r
+}
+
+func (r *Report) StepStarted(s *Step) {
+	r.stepStarted = time.Now()
+	r.wasInstalled = s.Installed != nil && s.Installed(r.ctx)
+	if s.Version != nil {
+		r.byID[s.ID].PreviousVersion = s.Version(r.ctx)
+	}
+}
+
+func (r *Report) StepFinished(s *Step, err error) {
+	e := r.byID[s.ID]
+	e.Reason = ""
+	e.DurationMS = time.Since(r.stepStarted).Milliseconds()
+	e.Warnings = r.ctx.Warnings
+	e.Changes = r.ctx.Changes
+	if s.Version != nil {
+		e.Version = s.Version(r.ctx)
+	}
+
+	installed := s.Installed != nil && s.Installed(r.ctx)
+	switch {
+	case err != nil:
+		e.Status = StatusFailed
+		e.Error = err.Error()
+	case s.Installed != nil && !r.wasInstalled && installed:
+		e.Status = StatusInstalled
+	case s.Installed != nil && r.wasInstalled && !installed:
+		e.Status = StatusRemoved
+	case s.Installed != nil && !installed:
+		// Not reported as installed: rollback uninstalls the steps a run
+		// reports as installed, and this one put nothing there to remove
+		e.Status = StatusUnchanged
+		e.Warnings = append(e.Warnings, "still not installed after the step ran")
+	case e.PreviousVersion != "" && e.Version != "" && e.PreviousVersion != e.Version:
+		e.Status = StatusUpdated
+	case s.Installed != nil || s.Version != nil:
+		e.Status = StatusUnchanged
+	default:
+		// Steps with nothing to probe, like apt upgrades, applied their changes
+		e.Status = StatusUpdated
+	}
+	if e.PreviousVersion == e.Version {
+		e.PreviousVersion = ""
+	}
+}
+
+func (r *Report) Finish() {
+	r.Finished = time.Now()
+}
+
+func (r *Report) Count(status string) int {
+	n := 0
+	for _, e := range r.Entries {
+		if e.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+func (e *ReportEntry) versionText() string {
+	if e.PreviousVersion != "" {
+		return e.PreviousVersion + " → " + e.Version
+	}
+	return e.Version
+}
+
+func (e *ReportEntry) durationText() string {
+	if e.Status == StatusSkipped {
+		return ""
+	}
+	return (time.Duration(e.DurationMS) * time.Millisecond).Round(100 * time.Millisecond).String()
+}
+
+func (e *ReportEntry) notes() string {
+	var notes []string
+	if e.Reason != "" {
+		notes = append(notes, e.Reason)
+	}
+	if e.Error != "" {
+		notes = append(notes, e.Error)
+	}
+	for _, w := range e.Warnings {
+		notes = append(notes, "warning: "+w)
+	}
+	if len(e.Changes) > 0 {
+		notes = append(notes, fmt.Sprintf("%d changes", len(e.Changes)))
+	}
+	return strings.Join(notes, "; ")
+}
+
+var statusColors = map[string]lipgloss.Color{
+	StatusInstalled: lipgloss.Color("10"),
+	StatusUpdated:   lipgloss.Color("12"),
+	StatusUnchanged: lipgloss.Color("7"),
+	StatusRemoved:   lipgloss.Color("13"),
+	StatusSkipped:   lipgloss.Color("8"),
+	StatusFailed:    lipgloss.Color("9"),
+}
+
+func (r *Report) summary() string {
+	counts := fmt.Sprintf("%d installed, %d updated, %d unchanged, ",
+		r.Count(StatusInstalled), r.Count(StatusUpdated), r.Count(StatusUnchanged))
+	if n := r.Count(StatusRemoved); n > 0 {
+		counts += fmt.Sprintf("%d removed, ", n)
+	}
+	return fmt.Sprintf("%s%d skipped, %d failed in %s",
+		counts, r.Count(StatusSkipped), r.Count(StatusFailed), r.Finished.Sub(r.Started).Round(time.Second))
+}
+
+// Table renders the report for the terminal.
+func (r *Report) Table() string {
+	t := table.New().
+		Border(lipgloss.NormalBorder()).
+		Headers("Step", "Status", "Version", "Duration", "Notes").
+		StyleFunc(func(row, col int) lipgloss.Style {
+			style := lipgloss.NewStyle().Padding(0, 1)
+			if row == 0 {
+				return style.Bold(true)
+			}
+			if col == 1 {
+				return style.Foreground(statusColors[r.Entries[row-1].Status])
+			}
+			return style
+		})
+	for _, e := range r.Entries {
+		t.Row(e.Step, e.Status, e.versionText(), e.durationText(), e.notes())
+	}
+	return t.Render() + "\n" + r.summary()
+}
+
+func (r *Report) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Bootstrap run %s\n\n", r.RunID)
+	fmt.Fprintf(&b, "%s.\n\n", r.summary())
+	b.WriteString("| Step | Status | Version | Duration | Notes |\n")
+	b.WriteString("| --- | --- | --- | --- | --- |\n")
+	cell := strings.NewReplacer("|", `\|`, "\n", " ")
+	for _, e := range r.Entries {
+		fmt.Fprintf(&b, "| %s | %s | %s | %s | %s |\n",
+			cell.Replace(e.Step), e.Status, cell.Replace(e.versionText()), e.durationText(), cell.Replace(e.notes()))
+	}
+	return b.String()
+}
+
+// ReadReport loads the JSON report written for a run.
+func ReadReport(dir string) (*Report, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "report.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Report{}
+	if err := json.Unmarshal(data, r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Write saves the report as report.md and report.json in dir.
+func (r *Report) Write(dir string) error {
+	if err := os.WriteFile(filepath.Join(dir, "report.md"), []byte(r.Markdown()), 0644); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, "report.json"), append(data, '\n'), 0644)
+}
@@ -0,0 +1,63 @@
+package steps
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestReportStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		before   bool
+		after    bool
+		err      error
+		want     string
+		warnings int
+	}{
+		{name: "installed", before: false, after: true, want: StatusInstalled},
+		{name: "already installed", before: true, after: true, want: StatusUnchanged},
+		{name: "removed", before: true, after: false, want: StatusRemoved},
+		{name: "still missing", before: false, after: false, want: StatusUnchanged, warnings: 1},
+		{name: "failed", before: false, after: true, err: errors.New("boom"), want: StatusFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			installed := tt.before
+			s := &Step{
+				ID:        "thing",
+				Installed: func(ctx *Context) bool { return installed },
+				Run: func(ctx *Context) error {
+					installed = tt.after
+					return tt.err
+				},
+			}
+			ctx := &Context{}
+			report := NewReport(ctx, "run", &Selection{Decisions: []Decision{{Step: s, Included: true}}})
+			_ = Run(ctx, []*Step{s}, report)
+
+			e := report.Entries[0]
+			if e.Status != tt.want {
+				t.Errorf("status = %s, want %s", e.Status, tt.want)
+			}
+			if len(e.Warnings) != tt.warnings {
+				t.Errorf("warnings = %q, want %d", e.Warnings, tt.warnings)
+			}
+		})
+	}
+}
+
+func TestRunResetsWarnings(t *testing.T) {
+	warn := func(ctx *Context) error {
+		ctx.Warn(errors.New("careful"))
+		ctx.Change("changed")
+		return nil
+	}
+	ctx := &Context{}
+	if err := Run(ctx, []*Step{{ID: "a", Run: warn}, {ID: "b", Run: warn}}); err != nil {
+		t.Fatal(err)
+	}
+	// Without a report the second step still only sees its own
+	if len(ctx.Warnings) != 1 || len(ctx.Changes) != 1 {
+		t.Errorf("warnings = %q, changes = %q, want one of each", ctx.Warnings, ctx.Changes)
+	}
+}
@@ -0,0 +1,39 @@
+package steps
+
+import (
+	"fmt"
+
+	u "github.com/timmo001/bootstrap/utils"
+)
+
+// Observer is notified as the runner moves through the steps.
+type Observer interface {
+	StepStarted(s *Step)
+	StepFinished(s *Step, err error)
+}
+
+// Run executes the steps in order, stopping at the first failure.
+func Run(ctx *Context, steps []*Step, observers ...Observer) error {
+	for _, s := range steps {
+		if err := u.Interrupted(); err != nil {
+			return err
+		}
+		// Warnings and changes are collected per step
+		ctx.Warnings, ctx.Changes = nil, nil
+		for _, o := range observers {
+			o.StepStarted(s)
+		}
+		u.PrintSeparator(s.Title)
+
+		// Set up the environment first so the step can use what it installs
+		ApplyEnv(ctx, s)
+		err := s.Run(ctx)
+		for _, o := range observers {
+			o.StepFinished(s, err)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", s.ID, err)
+		}
+	}
+	return nil
+}
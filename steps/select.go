@@ -0,0 +1,147 @@
+package steps
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/log"
+)
+
+// Filter narrows the registry down to the steps to run.
+type Filter struct {
+	Only []string
+	Skip []string
+	Tags []string
+}
+
+// Decision records whether a step will run and why.
+type Decision struct {
+	Step     *Step
+	Included bool
+	Reason   string
+}
+
+type Selection struct {
+	Decisions []Decision
+}
+
+func (r *Registry) Validate(f Filter) error {
+	for _, id := range append(append([]string{}, f.Only...), f.Skip...) {
+		if _, ok := r.byID[id]; !ok {
+			return fmt.Errorf("unknown step: %s", id)
+		}
+	}
+
+	tags := map[string]bool{}
+	for _, t := range r.Tags() {
+		tags[t] = true
+	}
+	for _, t := range f.Tags {
+		if !tags[t] {
+			return fmt.Errorf("unknown tag: %s (available: %s)", t, strings.Join(r.Tags(), ", "))
+		}
+	}
+
+	return nil
+}
+
+func (r *Registry) Select(ctx *Context, f Filter) (*Selection, error) {
+	if err := r.Validate(f); err != nil {
+		return nil, err
+	}
+
+	only := toSet(f.Only)
+	skip := toSet(f.Skip)
+
+	decisions := make([]Decision, len(r.steps))
+	index := map[string]int{}
+	for i, s := range r.steps {
+		index[s.ID] = i
+		d := Decision{Step: s, Included: true, Reason: "default"}
+
+		switch {
+		case skip[s.ID]:
+			d.Included, d.Reason = false, "skipped with --skip"
+		case len(only) > 0 || len(f.Tags) > 0:
+			if only[s.ID] {
+				d.Reason = "selected with --only"
+			} else if tag := firstMatchingTag(s, f.Tags); tag != "" {
+				d.Reason = "matches tag " + tag
+			} else {
+				d.Included, d.Reason = false, "not selected by --only or --tags"
+			}
+		}
+
+		if d.Included && s.SkipIf != nil {
+			if reason := s.SkipIf(ctx); reason != "" {
+				d.Included, d.Reason = false, reason
+			}
+		}
+
+		decisions[i] = d
+	}
+
+	// Pull in dependencies. Requires always point backwards in the
+	// registry, so a single reverse pass handles transitive dependencies.
+	for i := len(decisions) - 1; i >= 0; i-- {
+		if !decisions[i].Included {
+			continue
+		}
+		for _, dep := range decisions[i].Step.Requires {
+			d := &decisions[index[dep]]
+			if d.Included {
+				continue
+			}
+			if skip[dep] {
+				log.Warnf("%s requires %s, but it was skipped with --skip", decisions[i].Step.ID, dep)
+				continue
+			}
+			if d.Step.SkipIf != nil && d.Step.SkipIf(ctx) != "" {
+				continue
+			}
+			d.Included, d.Reason = true, "required by "+decisions[i].Step.ID
+		}
+	}
+
+	return &Selection{Decisions: decisions}, nil
+}
+
+func (s *Selection) Steps() []*Step {
+	var steps []*Step
+	for _, d := range s.Decisions {
+		if d.Included {
+			steps = append(steps, d.Step)
+		}
+	}
+	return steps
+}
+
+func (s *Selection) Print() {
+	for _, d := range s.Decisions {
+		if d.Included {
+			log.Infof("Included: %-26s %s", d.Step.ID, d.Reason)
+		}
+	}
+	for _, d := range s.Decisions {
+		if !d.Included {
+			log.Infof("Excluded: %-26s %s", d.Step.ID, d.Reason)
+		}
+	}
+}
+
+func firstMatchingTag(s *Step, tags []string) string {
+	for _, t := range tags {
+		if s.HasTag(t) {
+			return t
+		}
+	}
+	return ""
+}
+
+func toSet(items []string) map[string]bool {
+	set := map[string]bool{}
+	for _, item := range items {
+		set[item] = true
+	}
+	return set
+}
@@ -0,0 +1,135 @@
+package steps
+
+import (
+	"github.com/charmbracelet/log"
+)
+
+// Context holds the answers and flags shared by every step in a run.
+type Context struct {
+	Home      string
+	Shell     string
+	Force     bool
+	IsDesktop bool
+	IsWSL     bool
+	Email     string
+	Name      string
+	// GitProfiles are extra git identities, each for the repos under a directory
+	GitProfiles []GitProfile
+	// GitMirrors maps URL prefixes to the mirrors git fetches them from instead
+	GitMirrors map[string]string
+	// GitHubLogin logs gh in to GitHub and adds the ssh key to the account
+	GitHubLogin bool
+	// Signing is the kind of key commits are signed with, or "" to not sign
+	Signing string
+
+	// Dotfiles lists the dotfiles packages to link, or all when empty, and
+	// DotfilesConflict is what to do with existing files in the way
+	Dotfiles         []string
+	DotfilesConflict string
+
+	// Fonts lists the Nerd Fonts families to install, or the defaults when
+	// empty, and SystemFonts installs them for every user
+	Fonts       []string
+	SystemFonts bool
+
+	// Warnings collects problems the current step carried on past
+	Warnings []string
+	// Changes lists what the current step changed on disk, for the report
+	Changes []string
+}
+
+// GitProfile is a git identity used for repos under Dir, such as "~/work/".
+type GitProfile struct {
+	Name  string
+	Dir   string
+	Email string
+	User  string
+}
+
+// Commit signing key kinds.
+const (
+	SigningSSH = "ssh"
+	SigningGPG = "gpg"
+)
+
+// Warn logs a problem that doesn't stop the step, and records it for the report.
+func (ctx *Context) Warn(err error) {
+	log.Errorf("error: %v", err)
+	ctx.Warnings = append(ctx.Warnings, err.Error())
+}
+
+// Change records a change the step made, for the report.
+func (ctx *Context) Change(change string) {
+	ctx.Changes = append(ctx.Changes, change)
+}
+
+const (
+	CategorySystem    = "System"
+	CategoryShell     = "Shell"
+	CategoryLanguages = "Languages"
+	CategoryCLI       = "CLI tools"
+	CategoryDesktop   = "Desktop apps"
+	CategoryGaming    = "Gaming"
+	CategoryHyprland  = "Hyprland"
+)
+
+// Step is a single unit of work in the bootstrap sequence.
+type Step struct {
+	// ID is the name used on the command line, e.g. "fzf"
+	ID string
+	// Title is printed in the separator before the step runs
+	Title    string
+	Category string
+	Tags     []string
+	// Requires lists the IDs of steps that must run before this one
+	Requires []string
+	// SkipIf returns a reason to leave the step out of the run, or "" to keep it
+	SkipIf func(ctx *Context) string
+	// Installed reports whether the component is already on the machine
+	Installed func(ctx *Context) bool
+	// Version returns the installed version, or "" if it can't be detected
+	Version func(ctx *Context) string
+	// Probes are run by the doctor command to verify the component works
+	Probes []Probe
+	// Env is added to PATH and the environment once the component is installed
+	Env *Env
+	// Interactive reports whether Run prompts the user, which the dashboard
+	// can't pass on
+	Interactive func(ctx *Context) bool
+	// Plan describes the changes Run would make, for --dry-run
+	Plan func(ctx *Context) ([]string, error)
+	Run  func(ctx *Context) error
+	// Update brings an installed component up to date, if it has a way to
+	Update func(ctx *Context) error
+	// Uninstall removes the component, if it has a way to
+	Uninstall func(ctx *Context) error
+}
+
+// FindInteractive returns the first step that prompts the user, or nil.
+func FindInteractive(ctx *Context, steps []*Step) *Step {
+	for _, s := range steps {
+		if s.Interactive != nil && s.Interactive(ctx) {
+			return s
+		}
+	}
+	return nil
+}
+
+func (s *Step) State(ctx *Context) string {
+	if s.Installed == nil {
+		return ""
+	}
+	if s.Installed(ctx) {
+		return "installed"
+	}
+	return "not installed"
+}
+
+func (s *Step) HasTag(tag string) bool {
+	for _, t := range s.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,89 @@
+package steps
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/log"
+)
+
+// SelectUninstalls picks the named steps to uninstall. Nothing else is
+// pulled in, but installed steps that require them are warned about.
+func (r *Registry) SelectUninstalls(ctx *Context, ids []string) (*Selection, error) {
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("no components given to uninstall")
+	}
+	named := toSet(ids)
+	for _, id := range ids {
+		s, ok := r.byID[id]
+		if !ok {
+			return nil, fmt.Errorf("unknown step: %s", id)
+		}
+		if s.Uninstall == nil {
+			return nil, fmt.Errorf("%s has no uninstall path", id)
+		}
+	}
+
+	var decisions []Decision
+	for _, s := range r.steps {
+		d := Decision{Step: s, Reason: "not selected"}
+		if named[s.ID] {
+			d.Included, d.Reason = true, "selected"
+		}
+		decisions = append(decisions, d)
+
+		if named[s.ID] || s.Installed == nil || !s.Installed(ctx) {
+			continue
+		}
+		for _, dep := range s.Requires {
+			if named[dep] {
+				log.Warnf("%s requires %s, which is being uninstalled", s.ID, dep)
+			}
+		}
+	}
+
+	return &Selection{Decisions: decisions}, nil
+}
+
+// SelectRollback picks the steps a run installed, to uninstall them again.
+// Updates and other changes can't be reverted and are left alone.
+func (r *Registry) SelectRollback(report *Report) *Selection {
+	statuses := map[string]string{}
+	for _, e := range report.Entries {
+		statuses[e.Step] = e.Status
+	}
+
+	var decisions []Decision
+	for _, s := range r.steps {
+		d := Decision{Step: s}
+		switch status := statuses[s.ID]; {
+		case status == StatusInstalled && s.Uninstall != nil:
+			d.Included, d.Reason = true, "installed by run "+report.RunID
+		case status == StatusInstalled:
+			d.Reason = "has no uninstall path"
+		case status == StatusUpdated:
+			d.Reason = "updated, which can't be reverted"
+		case status == "":
+			d.Reason = "not in run " + report.RunID
+		default:
+			d.Reason = status
+		}
+		decisions = append(decisions, d)
+	}
+
+	return &Selection{Decisions: decisions}
+}
+
+// Uninstalls returns the selected steps set up to run their uninstall path,
+// in reverse order so dependents are removed first.
+func (s *Selection) Uninstalls() []*Step {
+	selected := s.Steps()
+	var uninstalls []*Step
+	for i := len(selected) - 1; i >= 0; i-- {
+		uninstall := *selected[i]
+		uninstall.Title = "Uninstall " + selected[i].Title
+		uninstall.Run = selected[i].Uninstall
+		uninstall.Plan = nil
+		uninstalls = append(uninstalls, &uninstall)
+	}
+	return uninstalls
+}
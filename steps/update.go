@@ -0,0 +1,75 @@
+package steps
+
+import (
+	"fmt"
+)
+
+// SelectUpdates picks the named steps to update, or every installed step
+// with an update path when no names are given. Prerequisite actions such as
+// refreshing package lists are pulled in, but other components are not.
+func (r *Registry) SelectUpdates(ctx *Context, ids []string) (*Selection, error) {
+	named := toSet(ids)
+	for _, id := range ids {
+		s, ok := r.byID[id]
+		if !ok {
+			return nil, fmt.Errorf("unknown step: %s", id)
+		}
+		if s.Update == nil {
+			return nil, fmt.Errorf("%s has no update path", id)
+		}
+	}
+
+	decisions := make([]Decision, len(r.steps))
+	index := map[string]int{}
+	for i, s := range r.steps {
+		index[s.ID] = i
+		d := Decision{Step: s}
+
+		switch {
+		case s.Update == nil:
+			d.Reason = "no update path"
+		case len(named) > 0:
+			if named[s.ID] {
+				d.Included, d.Reason = true, "selected"
+			} else {
+				d.Reason = "not selected"
+			}
+		case s.SkipIf != nil && s.SkipIf(ctx) != "":
+			d.Reason = s.SkipIf(ctx)
+		case s.Installed != nil && !s.Installed(ctx):
+			d.Reason = "not installed"
+		default:
+			d.Included, d.Reason = true, "has an update path"
+		}
+
+		decisions[i] = d
+	}
+
+	for i := len(decisions) - 1; i >= 0; i-- {
+		if !decisions[i].Included {
+			continue
+		}
+		for _, dep := range decisions[i].Step.Requires {
+			d := &decisions[index[dep]]
+			if d.Included || d.Step.Update == nil || d.Step.Installed != nil {
+				continue
+			}
+			d.Included, d.Reason = true, "required by "+decisions[i].Step.ID
+		}
+	}
+
+	return &Selection{Decisions: decisions}, nil
+}
+
+// Updates returns the selected steps set up to run their update path.
+func (s *Selection) Updates() []*Step {
+	var updates []*Step
+	for _, step := range s.Steps() {
+		update := *step
+		update.Title = "Update " + step.Title
+		update.Run = step.Update
+		update.Plan = nil
+		updates = append(updates, &update)
+	}
+	return updates
+}
@@ -0,0 +1,360 @@
+package tui
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/bubbles/progress"
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/mattn/go-isatty"
+
+	"github.com/timmo001/bootstrap/steps"
+	u "github.com/timmo001/bootstrap/utils"
+)
+
+const tailLines = 12
+
+type status int
+
+const (
+	pending status = iota
+	running
+	done
+	failed
+)
+
+type (
+	stepStartedMsg  struct{ index int }
+	stepFinishedMsg struct {
+		index int
+		err   error
+	}
+	lineMsg string
+	doneMsg struct{ err error }
+	tickMsg time.Time
+)
+
+var (
+	titleStyle   = lipgloss.NewStyle().Bold(true)
+	pendingStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
+	doneStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("10"))
+	failedStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+	tailStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("7")).PaddingLeft(2)
+)
+
+// Available reports whether the dashboard can be shown, which needs a terminal.
+func Available() bool {
+	return isatty.IsTerminal(os.Stdout.Fd())
+}
+
+// Run executes the steps behind a progress dashboard, sending all command
+// output and logs into it instead of the terminal.
+func Run(ctx *steps.Context, s []*steps.Step, observers ...steps.Observer) error {
+	// Nothing can be typed into a command while the dashboard is up
+	if step := steps.FindInteractive(ctx, s); step != nil {
+		return fmt.Errorf("%s prompts in the terminal, run it without the dashboard", step.ID)
+	}
+
+	// Commands can't prompt for a sudo password while the dashboard owns the
+	// terminal, so ask for it now and keep it cached for the whole run
+	if err := u.RunCmd("sudo", "-v"); err != nil {
+		return err
+	}
+	stopSudo := keepSudoAlive()
+	defer stopSudo()
+
+	m := newModel(s)
+	p := tea.NewProgram(m, tea.WithAltScreen())
+
+	w := &lineWriter{send: func(line string) { p.Send(lineMsg(line)) }}
+	stdin, stdout, stderr := u.Stdin, u.Stdout, u.Stderr
+	u.Stdin, u.Stdout, u.Stderr = nil, w, w
+	defer func() {
+		u.Stdin, u.Stdout, u.Stderr = stdin, stdout, stderr
+	}()
+
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		err := steps.Run(ctx, s, append([]steps.Observer{&observer{p: p, index: indexSteps(s)}}, observers...)...)
+		w.Flush()
+		p.Send(doneMsg{err: err})
+	}()
+
+	final, err := p.Run()
+	// The step mustn't outlive the dashboard, it shares the output and the
+	// context with whatever runs next
+	if err != nil || !final.(model).finished {
+		u.Interrupt()
+	}
+	<-finished
+	if err != nil {
+		return err
+	}
+
+	// Leave the final state on screen once the alternate screen is gone
+	fm := final.(model)
+	fmt.Println(fm.summary())
+	if fm.interrupted {
+		return fmt.Errorf("interrupted")
+	}
+	return fm.err
+}
+
+func keepSudoAlive() func() {
+	stop := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				// Not through utils, whose output the dashboard swaps out
+				_ = exec.Command("sudo", "-n", "-v").Run()
+			}
+		}
+	}()
+	return func() { close(stop) }
+}
+
+func indexSteps(s []*steps.Step) map[*steps.Step]int {
+	index := map[*steps.Step]int{}
+	for i, step := range s {
+		index[step] = i
+	}
+	return index
+}
+
+type observer struct {
+	p     *tea.Program
+	index map[*steps.Step]int
+}
+
+func (o *observer) StepStarted(s *steps.Step) {
+	o.p.Send(stepStartedMsg{index: o.index[s]})
+}
+
+func (o *observer) StepFinished(s *steps.Step, err error) {
+	o.p.Send(stepFinishedMsg{index: o.index[s], err: err})
+}
+
+// lineWriter splits output into lines, treating carriage returns as line
+// ends so download progress bars don't pile up.
+type lineWriter struct {
+	mu   sync.Mutex
+	buf  bytes.Buffer
+	send func(string)
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf.Write(p)
+	for {
+		i := bytes.IndexAny(w.buf.Bytes(), "\r\n")
+		if i < 0 {
+			break
+		}
+		line := string(w.buf.Next(i + 1))
+		if line = ansi.Strip(strings.TrimRight(line, "\r\n")); line != "" {
+			w.send(line)
+		}
+	}
+	return len(p), nil
+}
+
+func (w *lineWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.buf.Len() > 0 {
+		w.send(w.buf.String())
+		w.buf.Reset()
+	}
+}
+
+type stepState struct {
+	step     *steps.Step
+	status   status
+	started  time.Time
+	duration time.Duration
+}
+
+type model struct {
+	steps       []stepState
+	current     int
+	tail        []string
+	started     time.Time
+	finished    bool
+	interrupted bool
+	err         error
+
+	spinner  spinner.Model
+	progress progress.Model
+	width    int
+	height   int
+}
+
+func newModel(s []*steps.Step) model {
+	states := make([]stepState, len(s))
+	for i, step := range s {
+		states[i] = stepState{step: step}
+	}
+	return model{
+		steps:    states,
+		current:  -1,
+		started:  time.Now(),
+		spinner:  spinner.New(spinner.WithSpinner(spinner.Dot)),
+		progress: progress.New(progress.WithDefaultGradient()),
+	}
+}
+
+func interrupt() tea.Msg {
+	u.Interrupt()
+	return nil
+}
+
+func tick() tea.Cmd {
+	return tea.Tick(time.Second, func(t time.Time) tea.Msg { return tickMsg(t) })
+}
+
+func (m model) Init() tea.Cmd {
+	return tea.Batch(m.spinner.Tick, tick())
+}
+
+func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if msg.String() == "ctrl+c" {
+			// Stop the running command and keep the dashboard up until the
+			// step ends, a second ctrl+c closes it straight away
+			if m.interrupted {
+				return m, tea.Quit
+			}
+			m.interrupted = true
+			return m, interrupt
+		}
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.progress.Width = min(msg.Width-4, 80)
+	case stepStartedMsg:
+		m.current = msg.index
+		m.steps[msg.index].status = running
+		m.steps[msg.index].started = time.Now()
+		m.tail = nil
+	case stepFinishedMsg:
+		st := &m.steps[msg.index]
+		st.duration = time.Since(st.started)
+		if msg.err != nil {
+			st.status = failed
+		} else {
+			st.status = done
+		}
+	case lineMsg:
+		m.tail = append(m.tail, string(msg))
+		if len(m.tail) > tailLines {
+			m.tail = m.tail[len(m.tail)-tailLines:]
+		}
+	case doneMsg:
+		m.finished = true
+		m.err = msg.err
+		return m, tea.Quit
+	case tickMsg:
+		return m, tick()
+	case spinner.TickMsg:
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+	}
+	return m, nil
+}
+
+func (m model) completed() int {
+	n := 0
+	for _, st := range m.steps {
+		if st.status == done || st.status == failed {
+			n++
+		}
+	}
+	return n
+}
+
+func (m model) stepLine(st stepState) string {
+	switch st.status {
+	case running:
+		return fmt.Sprintf("%s %s %s", m.spinner.View(), st.step.Title, pendingStyle.Render(formatDuration(time.Since(st.started))))
+	case done:
+		return doneStyle.Render("✓ "+st.step.Title) + " " + pendingStyle.Render(formatDuration(st.duration))
+	case failed:
+		return failedStyle.Render("✗ "+st.step.Title) + " " + pendingStyle.Render(formatDuration(st.duration))
+	default:
+		return pendingStyle.Render("○ " + st.step.Title)
+	}
+}
+
+func (m model) View() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("Bootstrapping") + " " + pendingStyle.Render(formatDuration(time.Since(m.started))))
+	if m.interrupted {
+		b.WriteString(" " + failedStyle.Render("stopping…"))
+	}
+	b.WriteString("\n\n")
+
+	// Show a window of steps around the current one so long lists fit
+	visible := len(m.steps)
+	if m.height > 0 {
+		visible = max(m.height-tailLines-8, 3)
+	}
+	first := max(0, min(m.current-visible/2, len(m.steps)-visible))
+	for i := first; i < len(m.steps) && i < first+visible; i++ {
+		b.WriteString(m.stepLine(m.steps[i]) + "\n")
+	}
+
+	b.WriteString("\n")
+	for _, line := range m.tail {
+		if m.width > 6 {
+			line = ansi.Truncate(line, m.width-4, "…")
+		}
+		b.WriteString(tailStyle.Render(line) + "\n")
+	}
+	for i := len(m.tail); i < tailLines; i++ {
+		b.WriteString("\n")
+	}
+
+	percent := 0.0
+	if len(m.steps) > 0 {
+		percent = float64(m.completed()) / float64(len(m.steps))
+	}
+	b.WriteString("\n" + m.progress.ViewAs(percent) + fmt.Sprintf(" %d/%d\n", m.completed(), len(m.steps)))
+
+	return b.String()
+}
+
+// summary renders the step list and, if a step failed, the end of its output.
+func (m model) summary() string {
+	var b strings.Builder
+	for _, st := range m.steps {
+		b.WriteString(m.stepLine(st) + "\n")
+	}
+	if m.err != nil && len(m.tail) > 0 {
+		b.WriteString("\n" + strings.Join(m.tail, "\n") + "\n")
+	}
+	b.WriteString(fmt.Sprintf("\nFinished %d/%d steps in %s\n", m.completed(), len(m.steps), formatDuration(time.Since(m.started))))
+	return b.String()
+}
+
+func formatDuration(d time.Duration) string {
+	return d.Round(time.Second).String()
+}
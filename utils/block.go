@@ -0,0 +1,207 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/log"
+
+	"github.com/timmo001/bootstrap/backups"
+)
+
+// The managed block bootstrap owns in files such as ~/.zshrc. Everything
+// outside it is left as the user wrote it.
+const (
+	BlockStart = "# >>> bootstrap >>>"
+	BlockEnd   = "# <<< bootstrap <<<"
+)
+
+// ReadBlock returns the content of the managed block in file, and whether
+// the file has one.
+func ReadBlock(file string) (string, bool, error) {
+	data, err := os.ReadFile(file)
+	if os.IsNotExist(err) {
+		return "", false, nil
+	} else if err != nil {
+		return "", false, err
+	}
+
+	lines := strings.Split(string(data), "\n")
+	start, end, err := findBlock(file, lines)
+	if err != nil || start < 0 {
+		return "", false, err
+	}
+	return strings.Join(lines[start+1:end], "\n"), true, nil
+}
+
+// WriteBlock replaces the managed block in file with content, appending a
+// block if there isn't one and creating the file if needed. Empty content
+// removes the block.
+func WriteBlock(file, content string) error {
+	// Write through symlinks, rc files are often linked from a dotfiles repo
+	if target, err := filepath.EvalSymlinks(file); err == nil {
+		file = target
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	text := string(data)
+
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	if text == "" {
+		lines = nil
+	}
+	start, end, err := findBlock(file, lines)
+	if err != nil {
+		return err
+	}
+
+	var block []string
+	if content != "" {
+		block = append(append([]string{BlockStart}, strings.Split(strings.TrimSuffix(content, "\n"), "\n")...), BlockEnd)
+	}
+
+	var updated []string
+	switch {
+	case start >= 0:
+		before := lines[:start]
+		// Take the blank line that was added with the block away with it
+		if len(block) == 0 && len(before) > 0 && before[len(before)-1] == "" {
+			before = before[:len(before)-1]
+		}
+		updated = append(append(append(updated, before...), block...), lines[end+1:]...)
+	case len(block) == 0:
+		return nil
+	default:
+		updated = lines
+		if len(updated) > 0 && updated[len(updated)-1] != "" {
+			updated = append(updated, "")
+		}
+		updated = append(updated, block...)
+	}
+
+	result := strings.Join(updated, "\n")
+	if result != "" {
+		result += "\n"
+	}
+	if result == text {
+		return nil
+	}
+
+	if len(block) == 0 {
+		log.Infof("Removing bootstrap block from %s", file)
+	} else {
+		log.Infof("Updating bootstrap block in %s", file)
+	}
+	return WriteFileAtomic(file, []byte(result))
+}
+
+func RemoveBlock(file string) error {
+	return WriteBlock(file, "")
+}
+
+// findBlock returns the line indexes of the block markers, or -1 if the
+// file has no block.
+func findBlock(file string, lines []string) (int, int, error) {
+	start, end := -1, -1
+	for i, line := range lines {
+		switch strings.TrimSpace(line) {
+		case BlockStart:
+			if start >= 0 {
+				return 0, 0, fmt.Errorf("%s has more than one bootstrap block", file)
+			}
+			start = i
+		case BlockEnd:
+			if start < 0 || end >= 0 {
+				return 0, 0, fmt.Errorf("%s has a stray %q line", file, BlockEnd)
+			}
+			end = i
+		}
+	}
+	if start >= 0 && end < 0 {
+		return 0, 0, fmt.Errorf("%s has an unterminated bootstrap block", file)
+	}
+	return start, end, nil
+}
+
+// WriteFileAtomic replaces file through a temporary file in the same
+// directory, keeping its permissions, so it is never left half written.
+// The previous content is backed up first.
+func WriteFileAtomic(file string, data []byte) error {
+	if old, err := os.ReadFile(file); err == nil && bytes.Equal(old, data) {
+		return nil
+	}
+	if err := backups.Save(file); err != nil {
+		return err
+	}
+
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(file); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(file), "."+filepath.Base(file)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Chmod(mode); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), file)
+}
+
+// CopyFile copies src over dst, backing up what was there.
+func CopyFile(src, dst string) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	log.Infof("Copying %s to %s", src, dst)
+	return WriteFileAtomic(dst, data)
+}
+
+// RemoveLines deletes every line of file that exactly matches one of lines.
+func RemoveLines(file string, lines ...string) error {
+	if target, err := filepath.EvalSymlinks(file); err == nil {
+		file = target
+	}
+	data, err := os.ReadFile(file)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	remove := map[string]bool{}
+	for _, line := range lines {
+		remove[line] = true
+	}
+	var kept []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if remove[line] {
+			log.Infof("Removing line from %s: %s", file, line)
+			continue
+		}
+		kept = append(kept, line)
+	}
+
+	if result := strings.Join(kept, "\n"); result != string(data) {
+		return WriteFileAtomic(file, []byte(result))
+	}
+	return nil
+}
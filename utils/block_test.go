@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteBlock(t *testing.T) {
+	const block = BlockStart + "\nexport A=1\n" + BlockEnd + "\n"
+	tests := []struct {
+		name    string
+		initial string
+		// missing leaves the file out
+		missing bool
+		content string
+		want    string
+		// removed is the file after RemoveBlock
+		removed string
+		wantErr string
+	}{
+		{name: "new file", missing: true, content: "export A=1", want: block, removed: ""},
+		{name: "appended", initial: "# mine\n", content: "export A=1", want: "# mine\n\n" + block, removed: "# mine\n"},
+		{name: "no final newline", initial: "# mine", content: "export A=1\n", want: "# mine\n\n" + block, removed: "# mine\n"},
+		{
+			name:    "replaced in place",
+			initial: "# before\n" + BlockStart + "\nexport A=0\n" + BlockEnd + "\n# after\n",
+			content: "export A=1",
+			want:    "# before\n" + block + "# after\n",
+			removed: "# before\n# after\n",
+		},
+		{name: "empty content removes", initial: "# mine\n\n" + block, content: "", want: "# mine\n", removed: "# mine\n"},
+		{name: "two blocks", initial: block + block, content: "export A=1", wantErr: "more than one"},
+		{name: "unterminated", initial: BlockStart + "\nexport A=0\n", content: "export A=1", wantErr: "unterminated"},
+		{name: "stray end", initial: BlockEnd + "\n", content: "export A=1", wantErr: "stray"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("XDG_STATE_HOME", t.TempDir())
+			file := filepath.Join(t.TempDir(), ".zshrc")
+			if !tt.missing {
+				writeFile(t, file, tt.initial)
+			}
+			read := func() string {
+				t.Helper()
+				data, err := os.ReadFile(file)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return string(data)
+			}
+
+			err := WriteBlock(file, tt.content)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				if got := read(); got != tt.initial {
+					t.Errorf("file changed on error to %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := read(); got != tt.want {
+				t.Errorf("after WriteBlock:\n%q\nwant\n%q", got, tt.want)
+			}
+
+			// Writing it again changes nothing
+			if err := WriteBlock(file, tt.content); err != nil {
+				t.Fatal(err)
+			}
+			if got := read(); got != tt.want {
+				t.Errorf("after a second WriteBlock:\n%q\nwant\n%q", got, tt.want)
+			}
+
+			content, found, err := ReadBlock(file)
+			if err != nil || found != (tt.content != "") || content != strings.TrimSuffix(tt.content, "\n") {
+				t.Errorf("ReadBlock = %q, %v, %v, want %q", content, found, err, tt.content)
+			}
+
+			if err := RemoveBlock(file); err != nil {
+				t.Fatal(err)
+			}
+			if got := read(); got != tt.removed {
+				t.Errorf("after RemoveBlock:\n%q\nwant\n%q", got, tt.removed)
+			}
+		})
+	}
+}
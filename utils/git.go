@@ -0,0 +1,354 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/log"
+)
+
+// What UpdateOrCloneRepo does with uncommitted changes in a checkout.
+const (
+	DirtyAbort = "abort"
+	DirtyStash = "stash"
+)
+
+// DirtyPolicy is DirtyAbort to stop at uncommitted changes, or DirtyStash
+// to stash them and carry on.
+var DirtyPolicy = DirtyAbort
+
+// RepoUpdate is the commit a checkout moved from and to. Old is empty for
+// a new clone.
+type RepoUpdate struct {
+	Old string
+	New string
+}
+
+func (r RepoUpdate) Changed() bool {
+	return r.Old != r.New
+}
+
+func (r RepoUpdate) String() string {
+	switch {
+	case r.Old == "":
+		return "cloned at " + ShortCommit(r.New)
+	case r.Old == r.New:
+		return "already at " + ShortCommit(r.New)
+	default:
+		return ShortCommit(r.Old) + " -> " + ShortCommit(r.New)
+	}
+}
+
+// UpdateOrCloneRepo brings the checkout in destDir to ref, a branch, tag or
+// commit, or the default branch when ref is empty, cloning it first if
+// needed. Branches are fast-forwarded and never reset over local commits,
+// tags and commits are checked out detached.
+func UpdateOrCloneRepo(repoURL, destDir, ref string) (RepoUpdate, error) {
+	var update RepoUpdate
+	url := RemoteURL(repoURL)
+
+	// New clones are shallow unless the history comes from a mirror,
+	// existing checkouts keep their history
+	shallow := true
+	if _, err := os.Stat(destDir); os.IsNotExist(err) {
+		if err := RunCmd("git", "init", "--quiet", destDir); err != nil {
+			return update, err
+		}
+		if err := RunCmdInDir(destDir, "git", "remote", "add", "origin", url); err != nil {
+			return update, err
+		}
+		borrowed, err := borrowObjects(repoURL, destDir)
+		if err != nil {
+			return update, err
+		}
+		shallow = !borrowed
+	} else {
+		if err := checkRepo(repoURL, destDir); err != nil {
+			return update, err
+		}
+		if err := cleanTree(destDir); err != nil {
+			return update, err
+		}
+		update.Old, _ = RepoHead(destDir)
+		out, _ := CmdOutput("git", "-C", destDir, "rev-parse", "--is-shallow-repository")
+		shallow = out == "true"
+	}
+
+	name, commit, err := ResolveRef(repoURL, ref)
+	if err != nil {
+		return update, err
+	}
+	branch, isBranch := strings.CutPrefix(name, "refs/heads/")
+	tracking := "refs/remotes/origin/" + branch
+
+	fetch := []string{"fetch", "--quiet", "--force"}
+	if shallow {
+		fetch = append(fetch, "--depth", "1")
+	}
+	var lastFetched string
+	switch {
+	case isBranch:
+		lastFetched, _ = CmdOutput("git", "-C", destDir, "rev-parse", "--verify", "--quiet", tracking)
+		fetch = append(fetch, "origin", "+"+name+":"+tracking)
+	case name == "":
+		fetch = append(fetch, "origin", commit)
+	case !strings.HasPrefix(name, "refs/"):
+		// HEAD, when the remote doesn't say which branch it is
+		fetch = append(fetch, "origin", name)
+	default:
+		fetch = append(fetch, "origin", "+"+name+":"+name)
+	}
+	if err := RunCmdInDir(destDir, "git", fetch...); err != nil {
+		return update, err
+	}
+	target, err := CmdOutput("git", "-C", destDir, "rev-parse", "FETCH_HEAD^{commit}")
+	if err != nil {
+		return update, err
+	}
+
+	if !isBranch {
+		if err := RunCmdInDir(destDir, "git", "-c", "advice.detachedHead=false", "checkout", "--quiet", "--detach", target); err != nil {
+			return update, err
+		}
+	} else {
+		local, _ := CmdOutput("git", "-C", destDir, "rev-parse", "--verify", "--quiet", "refs/heads/"+branch)
+		switch {
+		// Nothing was committed locally since the last fetch, or it is behind
+		case local == "", local == lastFetched, isAncestor(destDir, local, target):
+			if err := RunCmdInDir(destDir, "git", "checkout", "--quiet", "-B", branch, target); err != nil {
+				return update, err
+			}
+		case isAncestor(destDir, target, local):
+			log.Warnf("%s has local commits that aren't on origin/%s", destDir, branch)
+			if err := RunCmdInDir(destDir, "git", "checkout", "--quiet", branch); err != nil {
+				return update, err
+			}
+		default:
+			return update, fmt.Errorf("%s has diverged from origin/%s, rebase or reset it", destDir, branch)
+		}
+		if err := RunCmdInDir(destDir, "git", "branch", "--quiet", "--set-upstream-to", "origin/"+branch, branch); err != nil {
+			return update, err
+		}
+	}
+
+	if ExistsPath(filepath.Join(destDir, ".gitmodules")) {
+		if err := RunCmdInDir(destDir, "git", "submodule", "sync", "--quiet", "--recursive"); err != nil {
+			return update, err
+		}
+		args := []string{"submodule", "update", "--init", "--recursive"}
+		if shallow {
+			args = append(args, "--depth", "1")
+		}
+		if err := RunCmdInDir(destDir, "git", args...); err != nil {
+			return update, err
+		}
+	}
+
+	update.New, err = RepoHead(destDir)
+	return update, err
+}
+
+// checkRepo makes sure destDir is a checkout of the repo, so an update
+// never touches an unrelated directory.
+func checkRepo(repoURL, destDir string) error {
+	top, err := CmdOutput("git", "-C", destDir, "rev-parse", "--show-toplevel")
+	if err != nil || !samePath(top, destDir) {
+		return fmt.Errorf("%s exists but is not a git repository", destDir)
+	}
+
+	// The URL as configured, before any insteadOf rewrites
+	origin, err := CmdOutput("git", "-C", destDir, "config", "--get", "remote.origin.url")
+	if err != nil {
+		return fmt.Errorf("%s has no origin remote", destDir)
+	}
+	if !sameRemote(origin, repoURL) {
+		return fmt.Errorf("%s is a checkout of %s, not %s", destDir, origin, repoURL)
+	}
+	if url := RemoteURL(repoURL); origin != url {
+		return RunCmdInDir(destDir, "git", "remote", "set-url", "origin", url)
+	}
+	return nil
+}
+
+// IsCheckout reports whether dir is the top of a checkout of the repo.
+func IsCheckout(repoURL, dir string) bool {
+	top, err := CmdOutput("git", "-C", dir, "rev-parse", "--show-toplevel")
+	if err != nil || !samePath(top, dir) {
+		return false
+	}
+	origin, err := CmdOutput("git", "-C", dir, "config", "--get", "remote.origin.url")
+	return err == nil && sameRemote(origin, repoURL)
+}
+
+// sameRemote reports whether origin is the repo over SSH or HTTPS.
+func sameRemote(origin, repoURL string) bool {
+	for _, candidate := range remoteCandidates(repoURL) {
+		if normalizeURL(origin) == normalizeURL(candidate) {
+			return true
+		}
+	}
+	return false
+}
+
+// cleanTree stashes or refuses uncommitted changes to tracked files, as
+// the dirty policy says.
+func cleanTree(destDir string) error {
+	status, err := CmdOutput("git", "-C", destDir, "status", "--porcelain", "--untracked-files=no")
+	if err != nil || status == "" {
+		return err
+	}
+	if DirtyPolicy != DirtyStash {
+		return fmt.Errorf("%s has uncommitted changes, commit or stash them, or run with --dirty stash", destDir)
+	}
+	if err := RunCmdInDir(destDir, "git", "stash", "push", "--quiet", "--message", "bootstrap: before update"); err != nil {
+		return err
+	}
+	log.Warnf("Stashed the changes in %s, get them back with: git -C %s stash pop", destDir, destDir)
+	return nil
+}
+
+func isAncestor(dir, ancestor, commit string) bool {
+	_, err := CmdOutput("git", "-C", dir, "merge-base", "--is-ancestor", ancestor, commit)
+	return err == nil
+}
+
+func samePath(a, b string) bool {
+	a, errA := filepath.EvalSymlinks(a)
+	b, errB := filepath.EvalSymlinks(b)
+	return errA == nil && errB == nil && a == b
+}
+
+func normalizeURL(url string) string {
+	return strings.TrimSuffix(strings.TrimSuffix(url, "/"), ".git")
+}
+
+func RepoHead(dir string) (string, error) {
+	return CmdOutput("git", "-C", dir, "rev-parse", "HEAD")
+}
+
+func ShortCommit(commit string) string {
+	if len(commit) > 12 {
+		return commit[:12]
+	}
+	return commit
+}
+
+// A full commit hash. Servers only fetch commits by their full hash, and
+// builds record it to compare with the checkout's HEAD.
+var commitHash = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// ResolveRef looks up ref on the remote and returns the full ref name and
+// the commit it points to. An empty ref is the default branch, named as
+// the branch when the remote says which, a version pattern such as
+// "v0.10.x" picks the highest matching tag, and a ref the remote doesn't
+// know is taken to be a commit if it is a full commit hash.
+func ResolveRef(repoURL, ref string) (name, commit string, err error) {
+	var args []string
+	switch {
+	case ref == "":
+		args = []string{"--symref", RemoteURL(repoURL), "HEAD"}
+	case strings.HasSuffix(ref, ".x"):
+		args = []string{"--tags", RemoteURL(repoURL), strings.TrimSuffix(ref, "x") + "*"}
+	default:
+		// The peeled entry of an annotated tag only matches with its suffix
+		args = []string{RemoteURL(repoURL), ref, ref + "^{}"}
+	}
+	out, err := CmdOutput("git", append([]string{"ls-remote"}, args...)...)
+	if err != nil {
+		return "", "", fmt.Errorf("git ls-remote %s: %w", repoURL, err)
+	}
+
+	refs := map[string]string{}
+	var head string
+	for _, line := range strings.Split(out, "\n") {
+		sha, refName, ok := strings.Cut(line, "\t")
+		if !ok {
+			continue
+		}
+		// The branch HEAD points to, listed with --symref
+		if target, ok := strings.CutPrefix(sha, "ref: "); ok {
+			head = target
+			continue
+		}
+		// Annotated tags are listed twice, the peeled line is the commit
+		if peeled, ok := strings.CutSuffix(refName, "^{}"); ok {
+			refs[peeled] = sha
+		} else if _, ok := refs[refName]; !ok {
+			refs[refName] = sha
+		}
+	}
+
+	switch {
+	case ref == "":
+		name = "HEAD"
+		if head != "" {
+			name, refs[head] = head, refs["HEAD"]
+		}
+	case strings.HasSuffix(ref, ".x"):
+		pattern := "refs/tags/" + strings.TrimSuffix(ref, "x") + "*"
+		for refName := range refs {
+			if ok, _ := path.Match(pattern, refName); ok && (name == "" || CompareVersions(refName, name) > 0) {
+				name = refName
+			}
+		}
+		if name == "" {
+			return "", "", fmt.Errorf("no tag in %s matches %s", repoURL, ref)
+		}
+	default:
+		for _, candidate := range []string{"refs/tags/" + ref, "refs/heads/" + ref, ref} {
+			if _, ok := refs[candidate]; ok {
+				name = candidate
+				break
+			}
+		}
+		if name == "" {
+			if !commitHash.MatchString(ref) {
+				return "", "", fmt.Errorf("no ref %s in %s, pin commits by their full hash", ref, repoURL)
+			}
+			return "", ref, nil
+		}
+	}
+	return name, refs[name], nil
+}
+
+// CheckoutRef fetches a single ref (or commit) into destDir, cloning it if
+// needed, and checks it out.
+func CheckoutRef(repoURL, destDir, ref string) error {
+	url := RemoteURL(repoURL)
+	shallow := true
+	if _, err := os.Stat(destDir); os.IsNotExist(err) {
+		if err := RunCmd("git", "init", "--quiet", destDir); err != nil {
+			return err
+		}
+		if err := RunCmdInDir(destDir, "git", "remote", "add", "origin", url); err != nil {
+			return err
+		}
+		borrowed, err := borrowObjects(repoURL, destDir)
+		if err != nil {
+			return err
+		}
+		shallow = !borrowed
+	} else {
+		if current, _ := CmdOutput("git", "-C", destDir, "config", "--get", "remote.origin.url"); current != url {
+			// The policy or what is reachable has changed since the last build
+			if err := RunCmdInDir(destDir, "git", "remote", "set-url", "origin", url); err != nil {
+				return err
+			}
+		}
+		out, _ := CmdOutput("git", "-C", destDir, "rev-parse", "--is-shallow-repository")
+		shallow = out == "true"
+	}
+
+	fetch := []string{"fetch", "--force"}
+	if shallow {
+		fetch = append(fetch, "--depth", "1")
+	}
+	if err := RunCmdInDir(destDir, "git", append(fetch, "origin", ref)...); err != nil {
+		return err
+	}
+	return RunCmdInDir(destDir, "git", "-c", "advice.detachedHead=false", "checkout", "--quiet", "FETCH_HEAD")
+}
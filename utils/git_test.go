@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testRepo makes a repo with a main branch, lightweight and annotated
+// tags, and returns its path and the commit of each tag.
+func testRepo(t *testing.T) (string, map[string]string) {
+	t.Helper()
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	t.Setenv("GIT_AUTHOR_NAME", "t")
+	t.Setenv("GIT_AUTHOR_EMAIL", "t@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "t")
+	t.Setenv("GIT_COMMITTER_EMAIL", "t@example.com")
+
+	dir := t.TempDir()
+	run := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+
+	run("init", "-q", "-b", "main")
+	commits := map[string]string{}
+	for _, tag := range []string{"v0.9.0", "v0.10.1", "v0.10.2", "v0.11.0-rc1"} {
+		if err := os.WriteFile(filepath.Join(dir, "version"), []byte(tag), 0644); err != nil {
+			t.Fatal(err)
+		}
+		run("add", "version")
+		run("commit", "-q", "-m", tag)
+		if tag == "v0.10.2" {
+			run("tag", "-a", "-m", tag, tag)
+		} else {
+			run("tag", tag)
+		}
+		commits[tag] = run("rev-parse", "HEAD")
+	}
+	commits["main"] = commits["v0.11.0-rc1"]
+	return dir, commits
+}
+
+func TestResolveRef(t *testing.T) {
+	repo, commits := testRepo(t)
+
+	tests := []struct {
+		ref        string
+		wantName   string
+		wantCommit string
+		wantErr    string
+	}{
+		{ref: "", wantName: "refs/heads/main", wantCommit: commits["main"]},
+		{ref: "main", wantName: "refs/heads/main", wantCommit: commits["main"]},
+		{ref: "v0.9.0", wantName: "refs/tags/v0.9.0", wantCommit: commits["v0.9.0"]},
+		// Annotated tags resolve to the commit, not the tag object
+		{ref: "v0.10.2", wantName: "refs/tags/v0.10.2", wantCommit: commits["v0.10.2"]},
+		{ref: "v0.10.x", wantName: "refs/tags/v0.10.2", wantCommit: commits["v0.10.2"]},
+		{ref: "v0.12.x", wantErr: "no tag"},
+		{ref: commits["v0.9.0"], wantCommit: commits["v0.9.0"]},
+		// Servers refuse to fetch abbreviated hashes
+		{ref: commits["v0.9.0"][:7], wantErr: "full hash"},
+		{ref: "stabel", wantErr: "no ref stabel"},
+		{ref: "abc", wantErr: "no ref abc"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			name, commit, err := ResolveRef(repo, tt.ref)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if name != tt.wantName || commit != tt.wantCommit {
+				t.Errorf("ResolveRef(%q) = %q, %q, want %q, %q", tt.ref, name, commit, tt.wantName, tt.wantCommit)
+			}
+		})
+	}
+}
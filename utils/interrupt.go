@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// ErrInterrupted is returned by commands run after the user interrupted the
+// run, and by the one that was running.
+var ErrInterrupted = errors.New("interrupted")
+
+// Cancelled when the run is interrupted
+var runCtx, cancelRun = context.WithCancel(context.Background())
+
+// Interrupt stops the running command and makes every later one fail, so
+// the step running ends at its next command.
+func Interrupt() {
+	cancelRun()
+}
+
+// Interrupted returns ErrInterrupted once Interrupt has been called.
+func Interrupted() error {
+	if runCtx.Err() != nil {
+		return ErrInterrupted
+	}
+	return nil
+}
+
+// newCmd makes a command that is sent SIGTERM when the run is interrupted,
+// which sudo passes on to what it runs, and killed if it hasn't exited a
+// few seconds later.
+func newCmd(name string, arg ...string) *exec.Cmd {
+	cmd := exec.CommandContext(runCtx, name, arg...)
+	cmd.Cancel = func() error {
+		return cmd.Process.Signal(syscall.SIGTERM)
+	}
+	cmd.WaitDelay = 5 * time.Second
+	return cmd
+}
@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// GitCache is a directory of bare mirrors that new clones borrow objects
+// from, so only what the mirror lacks is downloaded. Empty to clone directly.
+var GitCache string
+
+// MirrorDir returns where the cache keeps its mirror of the repo, named
+// after the host and path so the SSH and HTTPS URLs share one.
+func MirrorDir(cache, repoURL string) string {
+	candidates := remoteCandidates(repoURL)
+	name := repoURL
+	for _, c := range candidates {
+		if rest, ok := strings.CutPrefix(c, "https://"); ok {
+			name = rest
+		}
+	}
+	name = strings.NewReplacer(":", "/", "@", "_").Replace(strings.TrimPrefix(name, "file://"))
+	return filepath.Join(cache, strings.TrimSuffix(strings.Trim(name, "/"), ".git")+".git")
+}
+
+// Mirrors lists the mirrors in the cache.
+func Mirrors(cache string) ([]string, error) {
+	var mirrors []string
+	err := filepath.WalkDir(cache, func(path string, d os.DirEntry, err error) error {
+		if os.IsNotExist(err) {
+			return filepath.SkipAll
+		} else if err != nil {
+			return err
+		}
+		if d.IsDir() && strings.HasSuffix(path, ".git") {
+			mirrors = append(mirrors, path)
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	return mirrors, err
+}
+
+// RefreshMirror fetches everything new into a mirror.
+func RefreshMirror(dir string) error {
+	return RunCmdInDir(dir, "git", "remote", "update", "--prune")
+}
+
+// borrowObjects points a new checkout at the cache's mirror of the repo,
+// creating or refreshing the mirror first. It reports whether a mirror is
+// in use, in which case fetches needn't be shallow.
+func borrowObjects(repoURL, destDir string) (bool, error) {
+	if GitCache == "" {
+		return false, nil
+	}
+
+	mirror := MirrorDir(GitCache, repoURL)
+	if ExistsPath(mirror) {
+		if err := RefreshMirror(mirror); err != nil {
+			return false, err
+		}
+	} else {
+		if err := os.MkdirAll(filepath.Dir(mirror), 0755); err != nil {
+			return false, err
+		}
+		if err := RunCmd("git", "clone", "--mirror", "--quiet", RemoteURL(repoURL), mirror); err != nil {
+			return false, err
+		}
+	}
+
+	// The same as clone --reference, the checkout reads the mirror's objects
+	alternates := filepath.Join(destDir, ".git", "objects", "info", "alternates")
+	if err := os.MkdirAll(filepath.Dir(alternates), 0755); err != nil {
+		return false, err
+	}
+	return true, os.WriteFile(alternates, []byte(filepath.Join(mirror, "objects")+"\n"), 0644)
+}
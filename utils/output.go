@@ -0,0 +1,62 @@
+package utils
+
+import (
+	"bufio"
+	"io"
+	"os"
+
+	"github.com/charmbracelet/log"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/muesli/termenv"
+)
+
+// logOutput sends the logger to wherever Stderr currently points, so
+// redirecting Stderr also redirects the log.
+type logOutput struct{}
+
+func (logOutput) Write(p []byte) (int, error) {
+	return Stderr.Write(p)
+}
+
+func init() {
+	log.SetOutput(logOutput{})
+	// The logger can't detect the terminal through the wrapper, so keep the
+	// colours it would have picked for os.Stderr
+	log.SetColorProfile(termenv.NewOutput(os.Stderr).EnvColorProfile())
+}
+
+// plainWriter removes terminal escape codes before writing, for log files.
+type plainWriter struct {
+	w io.Writer
+}
+
+func PlainWriter(w io.Writer) io.Writer {
+	return plainWriter{w: w}
+}
+
+func (p plainWriter) Write(b []byte) (int, error) {
+	if _, err := io.WriteString(p.w, ansi.Strip(string(b))); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// TailFile returns up to the last n lines of the file.
+func TailFile(file string, n int) ([]string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+		if len(lines) > n {
+			lines = lines[1:]
+		}
+	}
+	return lines, scanner.Err()
+}
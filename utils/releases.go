@@ -0,0 +1,183 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/charmbracelet/log"
+)
+
+// ReleasesAPI is the GitHub API, or a directory laid out like it with
+// repos/<owner>/<name>/releases files, to stand in for it offline. Asset
+// URLs in a directory's files are paths, relative to the directory.
+var ReleasesAPI = "https://api.github.com"
+
+func releasesOnline() bool {
+	return strings.HasPrefix(ReleasesAPI, "http://") || strings.HasPrefix(ReleasesAPI, "https://")
+}
+
+// Release is a GitHub release, with the fields bootstrap uses.
+type Release struct {
+	Tag        string  `json:"tag_name"`
+	Draft      bool    `json:"draft"`
+	Prerelease bool    `json:"prerelease"`
+	Assets     []Asset `json:"assets"`
+}
+
+// Asset is a file attached to a release.
+type Asset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+}
+
+// Releases lists the releases of a repo, given as "owner/name".
+func Releases(repo string) ([]Release, error) {
+	var data []byte
+	if releasesOnline() {
+		req, err := http.NewRequestWithContext(runCtx, "GET", ReleasesAPI+"/repos/"+repo+"/releases?per_page=100", nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", "application/vnd.github+json")
+		// Unauthenticated requests are limited to 60 an hour
+		if token := os.Getenv("GITHUB_TOKEN"); token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("listing releases of %s: %s", repo, resp.Status)
+		}
+		if data, err = io.ReadAll(resp.Body); err != nil {
+			return nil, err
+		}
+	} else {
+		var err error
+		if data, err = os.ReadFile(filepath.Join(ReleasesAPI, "repos", repo, "releases")); err != nil {
+			return nil, err
+		}
+	}
+
+	var releases []Release
+	if err := json.Unmarshal(data, &releases); err != nil {
+		return nil, fmt.Errorf("listing releases of %s: %w", repo, err)
+	}
+	return releases, nil
+}
+
+// Download fetches the asset to dest, or copies it out of the directory
+// standing in for the API.
+func (a *Asset) Download(dest string) error {
+	if releasesOnline() {
+		return DownloadFile(a.URL, dest)
+	}
+	src := a.URL
+	if !filepath.IsAbs(src) {
+		src = filepath.Join(ReleasesAPI, src)
+	}
+	log.Infof("Copying asset: %s", src)
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(dest, data, 0644)
+}
+
+// SelectRelease picks the highest release that meets the version
+// constraint, or the highest stable release for "latest". Drafts are
+// never picked, and pre-releases only when asked for exactly.
+func SelectRelease(releases []Release, constraint string) (*Release, error) {
+	var best *Release
+	for i, r := range releases {
+		switch {
+		case r.Draft:
+			continue
+		case constraint == "" || constraint == "latest":
+			if r.Prerelease {
+				continue
+			}
+		case r.Prerelease && r.Tag != constraint:
+			continue
+		case r.Tag != constraint && !MatchVersion(constraint, r.Tag):
+			continue
+		}
+		if best == nil || CompareVersions(r.Tag, best.Tag) > 0 {
+			best = &releases[i]
+		}
+	}
+	if best == nil {
+		return nil, fmt.Errorf("no release matches %s", constraint)
+	}
+	return best, nil
+}
+
+// FindAsset returns the release's asset whose name matches the glob.
+func (r *Release) FindAsset(pattern string) (*Asset, error) {
+	for i, a := range r.Assets {
+		if ok, _ := filepath.Match(pattern, a.Name); ok {
+			return &r.Assets[i], nil
+		}
+	}
+	return nil, fmt.Errorf("release %s has no asset matching %s", r.Tag, pattern)
+}
+
+// Platform holds the values asset patterns are templated on.
+type Platform struct {
+	// Distro, DistroVersion and Codename come from /etc/os-release, e.g.
+	// "ubuntu", "24.04" and "noble"
+	Distro        string
+	DistroVersion string
+	Codename      string
+	// Arch is the Debian architecture name, e.g. "amd64"
+	Arch string
+}
+
+// DetectPlatform reads the distro from /etc/os-release and the
+// architecture from dpkg.
+func DetectPlatform() Platform {
+	p := Platform{Arch: runtime.GOARCH}
+	if arch, err := CmdOutput("dpkg", "--print-architecture"); err == nil {
+		p.Arch = arch
+	}
+	data, err := os.ReadFile("/etc/os-release")
+	if err != nil {
+		return p
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		value = strings.Trim(value, `"'`)
+		switch key {
+		case "ID":
+			p.Distro = value
+		case "VERSION_ID":
+			p.DistroVersion = value
+		case "VERSION_CODENAME":
+			p.Codename = value
+		}
+	}
+	return p
+}
+
+// Expand fills in {distro}, {distro_version}, {codename}, {arch} and
+// {version}, the release's version without a leading "v".
+func (p Platform) Expand(pattern, version string) string {
+	return strings.NewReplacer(
+		"{distro}", p.Distro,
+		"{distro_version}", p.DistroVersion,
+		"{codename}", p.Codename,
+		"{arch}", p.Arch,
+		"{version}", strings.TrimPrefix(version, "v"),
+	).Replace(pattern)
+}
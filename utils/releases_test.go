@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, file, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSelectRelease(t *testing.T) {
+	releases := []Release{
+		{Tag: "v0.22.0"},
+		{Tag: "v0.23.1"},
+		{Tag: "v0.23.0"},
+		{Tag: "v0.24.0-rc1", Prerelease: true},
+		{Tag: "v0.25.0", Draft: true},
+	}
+	tests := []struct {
+		constraint string
+		want       string
+		wantErr    bool
+	}{
+		{constraint: "", want: "v0.23.1"},
+		{constraint: "latest", want: "v0.23.1"},
+		{constraint: "v0.22.0", want: "v0.22.0"},
+		{constraint: "0.23.x", want: "v0.23.1"},
+		{constraint: "<0.23.1", want: "v0.23.0"},
+		// Pre-releases only when named, drafts never
+		{constraint: ">=0.24", wantErr: true},
+		{constraint: "v0.24.0-rc1", want: "v0.24.0-rc1"},
+		{constraint: "v0.25.0", wantErr: true},
+		{constraint: "1.x", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.constraint, func(t *testing.T) {
+			r, err := SelectRelease(releases, tt.constraint)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("SelectRelease(%q) = %s, want an error", tt.constraint, r.Tag)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r.Tag != tt.want {
+				t.Errorf("SelectRelease(%q) = %s, want %s", tt.constraint, r.Tag, tt.want)
+			}
+		})
+	}
+}
+
+func TestReleasesDirectory(t *testing.T) {
+	dir := t.TempDir()
+	api := ReleasesAPI
+	ReleasesAPI = dir
+	t.Cleanup(func() { ReleasesAPI = api })
+
+	writeFile(t, filepath.Join(dir, "repos/owner/name/releases"), `[{"tag_name": "v1.0.0", "assets": [{"name": "app-1.0.0-amd64.deb", "browser_download_url": "assets/app-1.0.0-amd64.deb"}]}]`)
+	writeFile(t, filepath.Join(dir, "assets/app-1.0.0-amd64.deb"), "deb")
+
+	releases, err := Releases("owner/name")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := SelectRelease(releases, "latest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	asset, err := r.FindAsset(Platform{Arch: "amd64"}.Expand("app-{version}-{arch}.deb", r.Tag))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dest := filepath.Join(t.TempDir(), "app.deb")
+	if err := asset.Download(dest); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(dest); err != nil || string(data) != "deb" {
+		t.Errorf("downloaded %q, %v, want the asset's contents", data, err)
+	}
+
+	if _, err := Releases("owner/missing"); err == nil || !strings.Contains(err.Error(), "missing") {
+		t.Errorf("err = %v, want the missing repo", err)
+	}
+}
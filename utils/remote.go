@@ -0,0 +1,93 @@
+package utils
+
+import (
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/log"
+)
+
+// How clones choose between a repo's SSH and HTTPS URLs.
+const (
+	CloneAuto  = "auto"
+	CloneSSH   = "ssh"
+	CloneHTTPS = "https"
+)
+
+// ClonePolicy is the kind of URL tried first: CloneSSH, CloneHTTPS, or
+// CloneAuto for the kind the repo was given as.
+var ClonePolicy = CloneAuto
+
+var (
+	remoteMu sync.Mutex
+	remotes  = map[string]string{}
+)
+
+// RemoteURL returns the URL to reach a repo by: the first of its SSH and
+// HTTPS forms that answers, in the order the clone policy gives. HTTPS is
+// tried without credentials, so it is only a way in to public repos. If
+// neither answers the first is returned, for git to report the error.
+func RemoteURL(repoURL string) string {
+	remoteMu.Lock()
+	defer remoteMu.Unlock()
+	if url, ok := remotes[repoURL]; ok {
+		return url
+	}
+
+	candidates := remoteCandidates(repoURL)
+	url := candidates[0]
+	if len(candidates) > 1 {
+		for _, c := range candidates {
+			if reachable(c) {
+				url = c
+				break
+			}
+		}
+	}
+	if url != repoURL {
+		log.Infof("Using %s for %s", url, repoURL)
+	}
+	remotes[repoURL] = url
+	return url
+}
+
+// remoteCandidates returns the SSH and HTTPS forms of a git@host:path or
+// https://host/path URL in the order to try them. Other URLs are only
+// tried as they are.
+func remoteCandidates(repoURL string) []string {
+	var ssh, https string
+	if rest, ok := strings.CutPrefix(repoURL, "git@"); ok {
+		host, path, found := strings.Cut(rest, ":")
+		if !found {
+			return []string{repoURL}
+		}
+		ssh, https = repoURL, "https://"+host+"/"+path
+	} else if rest, ok := strings.CutPrefix(repoURL, "https://"); ok {
+		host, path, found := strings.Cut(rest, "/")
+		if !found {
+			return []string{repoURL}
+		}
+		ssh, https = "git@"+host+":"+path, repoURL
+	} else {
+		return []string{repoURL}
+	}
+
+	switch {
+	case ClonePolicy == CloneSSH, ClonePolicy == CloneAuto && repoURL == ssh:
+		return []string{ssh, https}
+	default:
+		return []string{https, ssh}
+	}
+}
+
+// reachable reports whether the remote answers without prompting for a
+// password, passphrase or host key.
+func reachable(url string) bool {
+	cmd := newCmd("git", "ls-remote", url, "HEAD")
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if os.Getenv("GIT_SSH_COMMAND") == "" {
+		cmd.Env = append(cmd.Env, "GIT_SSH_COMMAND=ssh -o BatchMode=yes -o ConnectTimeout=10")
+	}
+	return cmd.Run() == nil
+}
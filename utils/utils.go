@@ -1,12 +1,26 @@
 package utils
 
 import (
-	"bufio"
+	"fmt"
+	"io"
+	"net/http"
 	"os"
 	"os/exec"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/log"
+
+	"github.com/timmo001/bootstrap/events"
+)
+
+// Output of child processes goes through these so it can be redirected,
+// for example into the progress dashboard
+var (
+	Stdin  io.Reader = os.Stdin
+	Stdout io.Writer = os.Stdout
+	Stderr io.Writer = os.Stderr
 )
 
 func IsExecutableInstalled(name string) bool {
@@ -32,10 +46,92 @@ func DownloadFile(url, dest string) error {
 	log.Infof("Downloading file: %s", url)
 
 	// Download the file
-	cmd := exec.Command("curl", "-L", "-o", dest, url)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	return cmd.Run()
+	req, err := http.NewRequestWithContext(runCtx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("downloading %s: %s", url, resp.Status)
+	}
+
+	// Download next to dest and rename, so a failed download leaves nothing
+	// that looks finished
+	part := dest + ".part"
+	f, err := os.Create(part)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(part)
+	defer f.Close()
+
+	progress := &downloadProgress{url: url, total: resp.ContentLength}
+	if _, err := io.Copy(f, io.TeeReader(resp.Body, progress)); err != nil {
+		return err
+	}
+	progress.emit()
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(part, dest)
+}
+
+// RemoteFingerprint identifies what url serves without downloading it, from
+// the URL it redirects to and its ETag, Last-Modified and Content-Length.
+// It is "" when the server gives none of them.
+func RemoteFingerprint(url string) (string, error) {
+	req, err := http.NewRequestWithContext(runCtx, http.MethodHead, url, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("checking %s: %s", url, resp.Status)
+	}
+
+	var parts []string
+	if final := resp.Request.URL.String(); final != url {
+		parts = append(parts, final)
+	}
+	for _, header := range []string{"ETag", "Last-Modified"} {
+		if value := resp.Header.Get(header); value != "" {
+			parts = append(parts, value)
+		}
+	}
+	if len(parts) > 0 && resp.ContentLength > 0 {
+		parts = append(parts, strconv.FormatInt(resp.ContentLength, 10))
+	}
+	return strings.Join(parts, " "), nil
+}
+
+// downloadProgress reports download_progress events at most twice a second.
+type downloadProgress struct {
+	url   string
+	total int64
+	bytes int64
+	last  time.Time
+}
+
+func (p *downloadProgress) Write(b []byte) (int, error) {
+	p.bytes += int64(len(b))
+	if time.Since(p.last) >= 500*time.Millisecond {
+		p.emit()
+	}
+	return len(b), nil
+}
+
+func (p *downloadProgress) emit() {
+	p.last = time.Now()
+	events.Emit(events.Event{Type: events.DownloadProgress, URL: p.url, Bytes: p.bytes, Total: p.total})
 }
 
 func ExistsDir(dir string) (bool, error) {
@@ -50,93 +146,65 @@ func RunCmdNoInput(name string, arg ...string) error {
 	log.Infof("Running command: %s %v", name, arg)
 
 	// Run the command
-	cmd := exec.Command(name, arg...)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	return cmd.Run()
+	cmd := newCmd(name, arg...)
+	cmd.Stdout = Stdout
+	cmd.Stderr = Stderr
+	return runCmd(cmd)
 }
 
 func RunCmd(name string, arg ...string) error {
 	log.Infof("Running command: %s %v", name, arg)
 
 	// Run the command
-	cmd := exec.Command(name, arg...)
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	return cmd.Run()
+	cmd := newCmd(name, arg...)
+	cmd.Stdin = Stdin
+	cmd.Stdout = Stdout
+	cmd.Stderr = Stderr
+	return runCmd(cmd)
 }
 
 func RunCmdInDir(dir, name string, arg ...string) error {
 	log.Infof("Running command in directory: %s %s %v", dir, name, arg)
 
 	// Run the command
-	cmd := exec.Command(name, arg...)
+	cmd := newCmd(name, arg...)
 	cmd.Dir = dir
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	return cmd.Run()
+	cmd.Stdin = Stdin
+	cmd.Stdout = Stdout
+	cmd.Stderr = Stderr
+	return runCmd(cmd)
 }
 
-func IsLineInFile(file, line string) (bool, error) {
-	// Check if the line is in the file
-	// Open the file
-	f, err := os.Open(file)
-	if err != nil {
-		return false, err
-	}
-	defer f.Close()
+// runCmd runs the command, reporting it on the event stream.
+func runCmd(cmd *exec.Cmd) error {
+	events.Emit(events.Event{Type: events.CommandStarted, Command: cmd.Args[0], Args: cmd.Args[1:], Dir: cmd.Dir})
+	start := time.Now()
 
-	// Read the file line by line using scanner
-	scanner := bufio.NewScanner(f)
-
-	for scanner.Scan() {
-		if scanner.Text() == line {
-			return true, nil
-		}
+	err := cmd.Run()
+	if err != nil && Interrupted() != nil {
+		err = ErrInterrupted
 	}
 
-	return false, nil
-}
-
-func AddIfMissingToFile(file, line string) error {
-	if exists, err := IsLineInFile(file, line); err != nil {
-		log.Fatalf("error: %v", err)
-		return err
-	} else if exists {
-		// Line is already in the file
-		log.Infof("Line is already in %s: %s", file, line)
-		return nil
-	} else {
-		// Add the line to the file
-
-		// Open the file
-		f, err := os.OpenFile(file, os.O_APPEND|os.O_WRONLY, 0644)
-		if err != nil {
-			return err
-		}
-		defer f.Close()
-
-		// Write the line to the file
-		if _, err := f.WriteString(line + "\n"); err != nil {
-			return err
-		}
-
-		log.Infof("Added line to %s: %s", file, line)
-
-		return nil
+	exitCode := 0
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		exitCode = exitErr.ExitCode()
+	} else if err != nil {
+		exitCode = -1
 	}
-}
-
-func UpdateOrCloneRepo(repoURL, destDir string) error {
-	if _, err := os.Stat(destDir); os.IsNotExist(err) {
-		// Directory does not exist, clone the repo
-		return RunCmd("git", "clone", "--depth", "1", repoURL, destDir)
-	} else {
-		// Directory exists, pull the latest changes
-		return RunCmdInDir(destDir, "git", "pull")
+	e := events.Event{
+		Type:       events.CommandFinished,
+		Command:    cmd.Args[0],
+		Args:       cmd.Args[1:],
+		Dir:        cmd.Dir,
+		ExitCode:   events.ExitCode(exitCode),
+		DurationMS: events.Duration(time.Since(start)),
+	}
+	if err != nil {
+		e.Error = err.Error()
 	}
+	events.Emit(e)
+
+	return err
 }
 
 func PrintSeparator(msg ...string) {
@@ -148,3 +216,34 @@ func PrintSeparator(msg ...string) {
 	log.Print("")
 	log.Print("================================================================================")
 }
+
+func SplitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func SplitLines(text string) []string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+func CmdOutput(name string, arg ...string) (string, error) {
+	// Run the command without logging or streaming, for probing state
+	out, err := newCmd(name, arg...).Output()
+	return strings.TrimSpace(string(out)), err
+}
+
+func ExistsPath(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
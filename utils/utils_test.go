@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDownloadFile(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+			w.Write([]byte("complete"))
+		case "/cut":
+			// Promise more than is sent, so the client sees the body end early
+			w.Header().Set("Content-Length", "100")
+			w.Write([]byte("partial"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{path: "/ok", want: "complete"},
+		{path: "/cut", wantErr: true},
+		{path: "/missing", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			dest := filepath.Join(t.TempDir(), "file")
+			err := DownloadFile(srv.URL+tt.path, dest)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("download succeeded")
+				}
+				for _, f := range []string{dest, dest + ".part"} {
+					if _, err := os.Stat(f); !os.IsNotExist(err) {
+						t.Errorf("%s was left behind", filepath.Base(f))
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if data, _ := os.ReadFile(dest); string(data) != tt.want {
+				t.Errorf("downloaded %q, want %q", data, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package utils
+
+import (
+	"strconv"
+	"strings"
+)
+
+// CompareVersions compares two dotted version strings numerically, ignoring
+// any prefix before the first digit (such as "v" or "refs/tags/v"). It
+// returns -1, 0 or 1.
+func CompareVersions(a, b string) int {
+	as, bs := versionParts(a), versionParts(b)
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y int
+		if i < len(as) {
+			x = as[i]
+		}
+		if i < len(bs) {
+			y = bs[i]
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+func versionParts(version string) []int {
+	version = strings.TrimLeftFunc(version, func(r rune) bool { return r < '0' || r > '9' })
+	// Drop pre-release and build suffixes, e.g. "1.2.3-rc1"
+	if i := strings.IndexAny(version, "-+ "); i >= 0 {
+		version = version[:i]
+	}
+
+	var parts []int
+	for _, part := range strings.Split(version, ".") {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			break
+		}
+		parts = append(parts, n)
+	}
+	return parts
+}
+
+// MatchVersion reports whether version meets the constraint, a comma
+// separated list of clauses that must all hold. A clause is an exact
+// version, a pattern such as "0.23.x", a comparison such as ">=1.0" or
+// "<2", "^1.2" for the same major version (the same minor for "^0.23") or
+// "~1.2" for the same minor.
+func MatchVersion(constraint, version string) bool {
+	for _, clause := range strings.Split(constraint, ",") {
+		clause = strings.TrimSpace(clause)
+		op := ""
+		for _, o := range []string{">=", "<=", ">", "<", "=", "^", "~"} {
+			if rest, ok := strings.CutPrefix(clause, o); ok {
+				op, clause = o, strings.TrimSpace(rest)
+				break
+			}
+		}
+
+		c := CompareVersions(version, clause)
+		var ok bool
+		switch op {
+		case ">=":
+			ok = c >= 0
+		case "<=":
+			ok = c <= 0
+		case ">":
+			ok = c > 0
+		case "<":
+			ok = c < 0
+		case "^":
+			// Like semver, up to the first number that isn't zero
+			n := 1
+			for _, part := range versionParts(clause) {
+				if part != 0 {
+					break
+				}
+				n++
+			}
+			ok = c >= 0 && samePrefix(version, clause, min(n, len(versionParts(clause))))
+		case "~":
+			ok = c >= 0 && samePrefix(version, clause, 2)
+		default:
+			if strings.HasSuffix(clause, ".x") || strings.HasSuffix(clause, ".*") {
+				ok = samePrefix(version, clause, len(versionParts(clause)))
+			} else {
+				ok = c == 0
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// samePrefix reports whether the first n numbers of the versions match.
+func samePrefix(a, b string, n int) bool {
+	as, bs := versionParts(a), versionParts(b)
+	if len(as) < n || len(bs) < n {
+		return false
+	}
+	for i := 0; i < n; i++ {
+		if as[i] != bs[i] {
+			return false
+		}
+	}
+	return true
+}
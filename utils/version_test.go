@@ -0,0 +1,76 @@
+package utils
+
+import "testing"
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.2.3", "1.2.3", 0},
+		{"v1.2.3", "1.2.3", 0},
+		{"refs/tags/v1.2.3", "v1.2.3", 0},
+		{"1.2", "1.2.0", 0},
+		{"1.10.0", "1.9.0", 1},
+		{"0.9.9", "0.10.0", -1},
+		{"2", "1.99.99", 1},
+		// Pre-release and build suffixes are ignored
+		{"1.2.3-rc1", "1.2.3", 0},
+		{"1.2.3+build5", "1.2.2", 1},
+		{"nvim 0.10.2", "0.10.1", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.a+" vs "+tt.b, func(t *testing.T) {
+			if got := CompareVersions(tt.a, tt.b); got != tt.want {
+				t.Errorf("CompareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+			}
+			if got := CompareVersions(tt.b, tt.a); got != -tt.want {
+				t.Errorf("CompareVersions(%q, %q) = %d, want %d", tt.b, tt.a, got, -tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchVersion(t *testing.T) {
+	tests := []struct {
+		constraint string
+		version    string
+		want       bool
+	}{
+		{"0.23.1", "v0.23.1", true},
+		{"0.23.1", "0.23.2", false},
+		{"0.23.x", "0.23.9", true},
+		{"0.23.x", "0.24.0", false},
+		{"0.23.*", "0.23.0", true},
+		{"1.x", "1.99.0", true},
+		{"1.x", "2.0.0", false},
+		{">=0.23", "0.23.0", true},
+		{">=0.23", "0.22.9", false},
+		{">0.23", "0.23.0", false},
+		{"<=1.0", "1.0.0", true},
+		{"<2", "1.9.9", true},
+		{"<2", "2.0.0", false},
+		{"=1.2.3", "1.2.3", true},
+		{"^1.2", "1.9.0", true},
+		{"^1.2", "1.1.0", false},
+		{"^1.2", "2.0.0", false},
+		{"^0.23", "0.23.4", true},
+		{"^0.23", "0.24.0", false},
+		{"^0.23", "0.99.0", false},
+		{"^0.0.3", "0.0.3", true},
+		{"^0.0.3", "0.0.4", false},
+		{"~1.2", "1.2.7", true},
+		{"~1.2", "1.3.0", false},
+		// Every clause has to hold
+		{">=0.23, <0.24", "0.23.5", true},
+		{">=0.23, <0.24", "0.24.0", false},
+		{">= 1.0 , < 2.0", "1.5", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.constraint+" "+tt.version, func(t *testing.T) {
+			if got := MatchVersion(tt.constraint, tt.version); got != tt.want {
+				t.Errorf("MatchVersion(%q, %q) = %v, want %v", tt.constraint, tt.version, got, tt.want)
+			}
+		})
+	}
+}